@@ -1,32 +1,187 @@
 package main
 
 import (
-	"fmt"
-	"log"
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"auth/internal/db"
 	"auth/internal/platform/config"
+	"auth/internal/platform/geo"
+	"auth/internal/platform/health"
 	phttp "auth/internal/platform/http"
+	"auth/internal/platform/logging"
 	"auth/internal/platform/notify"
+	"auth/internal/platform/scheduler"
+	"auth/internal/platform/security"
+	"auth/internal/platform/tracing"
 
+	"auth/internal/modules/auth/domain"
 	authhttp "auth/internal/modules/auth/http"
+	"auth/migrations"
 )
 
 func main() {
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		fatal("load config", err)
+	}
+	logging.Setup(logging.Options{Level: logging.ParseLevel(cfg.LogLevel), Format: cfg.LogFormat})
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(cfg, os.Args[2:]))
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		ServiceName: "news-auth",
+		Exporter:    cfg.TraceExporter,
+		SampleRatio: cfg.TraceSampleRatio,
+	})
+	if err != nil {
+		fatal("setup tracing", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("flush traces", "err", err)
+		}
+	}()
 
 	dbpool := db.MustOpen(cfg.PGDSN)
 	defer dbpool.Close()
+	prometheus.MustRegister(db.NewPoolCollector(dbpool))
+
+	// на устаревшей схеме не стартуем: запросы упадут на отсутствующих колонках
+	migrator, err := db.NewMigrator(dbpool, migrations.FS)
+	if err != nil {
+		fatal("load migrations", err)
+	}
+	schemaCtx, cancelSchema := context.WithTimeout(context.Background(), 5*time.Second)
+	err = migrator.Check(schemaCtx)
+	cancelSchema()
+	if err != nil {
+		fatal("check schema version", err)
+	}
 
 	mailer := notify.NewMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPass, cfg.SMTPFrom)
 	// for production ensure this is false; can be enabled for local dev via SMTP_INSECURE_SKIP_VERIFY
 	mailer.InsecureSkipVerify = cfg.SMTPInsecureSkipVerify
-	fmt.Printf("SMTP: host=%s port=%d from=%s insecure_skip_verify=%v\n", cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPFrom, mailer.InsecureSkipVerify)
-	authModule := authhttp.NewModulePG(dbpool, cfg.JWTSecret, cfg.AccessTTL).WithMailer(mailer)
-	app := phttp.NewServer(phttp.Options{AppName: "news-auth"}, authModule)
+	slog.Info("smtp", "host", cfg.SMTPHost, "port", cfg.SMTPPort, "from", cfg.SMTPFrom, "insecure_skip_verify", mailer.InsecureSkipVerify)
+
+	security.SetHashParams(&argon2id.Params{
+		Memory:      cfg.Argon2Memory,
+		Iterations:  cfg.Argon2Iterations,
+		Parallelism: cfg.Argon2Parallelism,
+		SaltLength:  cfg.Argon2SaltLength,
+		KeyLength:   cfg.Argon2KeyLength,
+	})
+
+	pwPolicy := security.DefaultPasswordPolicy()
+	pwPolicy.MinLength = cfg.PasswordMinLength
+	if cfg.PasswordBreachFile != "" {
+		breached, err := security.NewPrefixFileChecker(cfg.PasswordBreachFile)
+		if err != nil {
+			fatal("open password breach file", err)
+		}
+		defer breached.Close()
+		pwPolicy.Breached = breached
+	}
+
+	locator, err := geo.Open(cfg.GeoIPDBPath)
+	if err != nil {
+		fatal("open geoip database", err)
+	}
+	defer locator.Close()
 
-	log.Printf("listening on %s", cfg.HTTPAddr)
-	if err := app.Listen(cfg.HTTPAddr); err != nil {
-		log.Fatal(err)
+	sessionPolicy := domain.SessionPolicy{
+		TTL:             cfg.SessionTTL,
+		RememberTTL:     cfg.SessionRememberTTL,
+		MaxLifetime:     cfg.SessionMaxLifetime,
+		IdleTimeout:     cfg.SessionIdleTimeout,
+		MaxActive:       cfg.SessionMaxActive,
+		MaxActiveByRole: map[domain.Role]int{},
+		EvictOnLimit:    cfg.SessionLimitPolicy != "reject",
 	}
+	for role, n := range cfg.SessionMaxActiveByRole {
+		sessionPolicy.MaxActiveByRole[domain.Role(role)] = n
+	}
+
+	authModule := authhttp.NewModulePG(dbpool, cfg.JWTSecret, cfg.AccessTTL).
+		WithMailer(mailer).
+		WithGeo(locator).
+		WithPasswordPolicy(pwPolicy).
+		WithSessionPolicy(sessionPolicy).
+		WithAccountDeletion(cfg.AccountDeletionGrace, cfg.AccountPurgeInterval).
+		WithCleanup(cfg.CleanupInterval, cfg.CleanupBatchSize, cfg.CodeRetention, cfg.SessionRetention).
+		WithPublicBaseURL(cfg.PublicBaseURL).
+		WithExport(cfg.ExportLinkTTL).
+		WithSessionActivity(cfg.SessionActivityFlush, cfg.SessionActivityThrottle)
+
+	// фоновые задачи останавливаем после HTTP-сервера, чтобы не потерять
+	// отметки активности от запросов, которые ещё дорабатывают
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	authModule.Start(bgCtx)
+
+	sched := scheduler.New(db.NewAdvisoryLocker(dbpool))
+	sched.Add(authModule.Jobs()...)
+	sched.Start(bgCtx)
+
+	checks := health.NewRegistry(cfg.HealthTimeout)
+	if cfg.RedisAddr != "" {
+		checks.Register("redis", health.Redis(cfg.RedisAddr))
+	}
+
+	app := phttp.NewServer(phttp.Options{
+		AppName:        "news-auth",
+		ReadTimeout:    cfg.HTTPReadTimeout,
+		WriteTimeout:   cfg.HTTPWriteTimeout,
+		IdleTimeout:    cfg.HTTPIdleTimeout,
+		BodyLimit:      cfg.HTTPBodyLimit,
+		RequestTimeout: cfg.HTTPRequestTimeout,
+		ProblemJSON:    cfg.ProblemJSON,
+		TrustedProxies: cfg.TrustedProxies,
+		Health:         checks,
+	}, authModule)
+
+	sigCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	listenErr := make(chan error, 1)
+	go func() {
+		slog.Info("listening", "addr", cfg.HTTPAddr)
+		listenErr <- app.Listen(cfg.HTTPAddr)
+	}()
+
+	select {
+	case err := <-listenErr:
+		if err != nil {
+			slog.Error("http server", "err", err)
+		}
+	case <-sigCtx.Done():
+		slog.Info("shutting down", "timeout", cfg.ShutdownTimeout.String())
+		if err := app.ShutdownWithTimeout(cfg.ShutdownTimeout); err != nil {
+			slog.Error("http shutdown", "err", err)
+		}
+	}
+
+	stopBackground()
+	sched.Wait()
+	authModule.Wait()
+	// пул соединений и остальные ресурсы закрываются отложенными вызовами выше
+	slog.Info("stopped")
+}
+
+// fatal — ошибка запуска: пишем в лог и выходим (отложенные вызовы не выполняются,
+// как и при log.Fatal).
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
 }
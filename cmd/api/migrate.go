@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"auth/internal/db"
+	"auth/internal/platform/config"
+	"auth/migrations"
+)
+
+const migrateUsage = `usage: api migrate <command>
+  up            применить все новые миграции
+  down [N]      откатить N последних миграций (по умолчанию 1, "all" — все)
+  status        версия схемы и неприменённые миграции
+  force V       записать версию V без выполнения миграций (после ручного исправления)`
+
+// runMigrate — подкоманда `migrate`; возвращает код выхода.
+func runMigrate(cfg config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	dbpool := db.MustOpen(cfg.PGDSN)
+	defer dbpool.Close()
+	m, err := db.NewMigrator(dbpool, migrations.FS)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "load migrations:", err)
+		return 1
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		n, err := m.Up(ctx)
+		fmt.Printf("applied %d migration(s)\n", n)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if args[1] == "all" {
+				steps = 0
+			} else if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				fmt.Fprintln(os.Stderr, migrateUsage)
+				return 2
+			}
+		}
+		n, err := m.Down(ctx, steps)
+		fmt.Printf("reverted %d migration(s)\n", n)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	case "status":
+		st, err := m.Status(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("version: %d (latest %d)", st.Version, st.Latest)
+		if st.Dirty {
+			fmt.Print(", DIRTY")
+		}
+		fmt.Println()
+		for _, mg := range m.Migrations() {
+			state := "applied"
+			if mg.Version > st.Version {
+				state = "pending"
+			}
+			fmt.Printf("  %04d %-30s %s\n", mg.Version, mg.Name, state)
+		}
+	case "force":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			return 2
+		}
+		v, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			return 2
+		}
+		if err := m.Force(ctx, v); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	return 0
+}
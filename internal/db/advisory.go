@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"hash/fnv"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AdvisoryLocker — блокировки на pg_try_advisory_lock: задачу с одним именем
+// выполняет только одна реплика. Сессионная блокировка живёт на соединении,
+// поэтому соединение держим до unlock.
+type AdvisoryLocker struct{ pool *pgxpool.Pool }
+
+func NewAdvisoryLocker(pool *pgxpool.Pool) *AdvisoryLocker { return &AdvisoryLocker{pool: pool} }
+
+func (l *AdvisoryLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	key := lockKey(name)
+	var ok bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&ok); err != nil {
+		conn.Release()
+		return nil, false, err
+	}
+	if !ok {
+		conn.Release()
+		return nil, false, nil
+	}
+	return func() {
+		// ctx задачи к этому моменту может быть отменён
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, key); err != nil {
+			slog.Error("advisory unlock", "lock", name, "err", err)
+			// соединение с неснятой блокировкой в пул не возвращаем
+			conn.Conn().Close(context.Background())
+		}
+		conn.Release()
+	}, true, nil
+}
+
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
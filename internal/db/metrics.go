@@ -0,0 +1,48 @@
+package db
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolCollector отдаёт статистику pgxpool в Prometheus (снимается при каждом scrape).
+type PoolCollector struct {
+	pool *pgxpool.Pool
+
+	acquired, idle, total, max *prometheus.Desc
+	acquires, emptyAcquires    *prometheus.Desc
+	acquireDuration            *prometheus.Desc
+}
+
+func NewPoolCollector(pool *pgxpool.Pool) *PoolCollector {
+	d := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc("pgxpool_"+name, help, nil, nil)
+	}
+	return &PoolCollector{
+		pool:            pool,
+		acquired:        d("acquired_conns", "Соединения, занятые запросами."),
+		idle:            d("idle_conns", "Свободные соединения."),
+		total:           d("total_conns", "Все открытые соединения."),
+		max:             d("max_conns", "Максимальный размер пула."),
+		acquires:        d("acquires_total", "Сколько раз брали соединение из пула."),
+		emptyAcquires:   d("empty_acquires_total", "Сколько раз пришлось ждать соединение (пул был пуст)."),
+		acquireDuration: d("acquire_duration_seconds_total", "Суммарное время ожидания соединения."),
+	}
+}
+
+func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.acquired, c.idle, c.total, c.max, c.acquires, c.emptyAcquires, c.acquireDuration} {
+		ch <- d
+	}
+}
+
+func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(c.acquired, prometheus.GaugeValue, float64(s.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(s.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(s.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.max, prometheus.GaugeValue, float64(s.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquires, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, s.AcquireDuration().Seconds())
+}
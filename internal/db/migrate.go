@@ -0,0 +1,275 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Migrator применяет SQL-миграции из fs.FS. Состояние хранится так же, как у
+// golang-migrate (таблица schema_migrations с одной строкой version, dirty),
+// поэтому базу, размеченную migrate CLI, можно вести дальше этим раннером и наоборот.
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration // по возрастанию версии
+}
+
+// Migration — пара файлов <версия>_<название>.up.sql / .down.sql.
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string // пусто — откатить нельзя
+}
+
+// SchemaState — версия схемы в базе и версия, которую ждёт код.
+type SchemaState struct {
+	Version uint64 // 0 — миграций ещё не было
+	Dirty   bool   // миграция Version упала на середине
+	Latest  uint64
+	Pending []Migration
+}
+
+var (
+	ErrSchemaDirty    = errors.New("schema is dirty: a migration failed halfway, fix it manually and run `migrate force <version>`")
+	ErrSchemaOutdated = errors.New("schema is outdated: run `migrate up`")
+)
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// NewMigrator читает миграции из корня fsys; файлы с другими именами пропускаются.
+func NewMigrator(pool *pgxpool.Pool, fsys fs.FS) (*Migrator, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[uint64]*Migration{}
+	for _, e := range entries {
+		m := migrationFile.FindStringSubmatch(e.Name())
+		if e.IsDir() || m == nil {
+			continue
+		}
+		v, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", e.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+		mg := byVersion[v]
+		if mg == nil {
+			mg = &Migration{Version: v, Name: m[2]}
+			byVersion[v] = mg
+		} else if mg.Name != m[2] {
+			return nil, fmt.Errorf("migration %d: conflicting names %q and %q", v, mg.Name, m[2])
+		}
+		if m[3] == "up" {
+			mg.Up = string(body)
+		} else {
+			mg.Down = string(body)
+		}
+	}
+
+	mr := &Migrator{pool: pool}
+	for _, mg := range byVersion {
+		if mg.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s: no up file", mg.Version, mg.Name)
+		}
+		mr.migrations = append(mr.migrations, *mg)
+	}
+	sort.Slice(mr.migrations, func(i, j int) bool { return mr.migrations[i].Version < mr.migrations[j].Version })
+	return mr, nil
+}
+
+// Migrations — все известные миграции по возрастанию версии.
+func (m *Migrator) Migrations() []Migration { return m.migrations }
+
+// Status читает версию схемы; таблицу schema_migrations не создаёт.
+func (m *Migrator) Status(ctx context.Context) (SchemaState, error) {
+	st := SchemaState{}
+	if len(m.migrations) > 0 {
+		st.Latest = m.migrations[len(m.migrations)-1].Version
+	}
+	var exists bool
+	if err := m.pool.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return st, err
+	}
+	if exists {
+		var v int64
+		err := m.pool.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&v, &st.Dirty)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return st, err
+		}
+		// golang-migrate пишет -1 при неудачном откате первой миграции
+		if v > 0 {
+			st.Version = uint64(v)
+		}
+	}
+	for _, mg := range m.migrations {
+		if mg.Version > st.Version {
+			st.Pending = append(st.Pending, mg)
+		}
+	}
+	return st, nil
+}
+
+// Check — можно ли работать с базой: схема не грязная и все миграции применены.
+// Схема новее кода (откат релиза при раскатке) допустима.
+func (m *Migrator) Check(ctx context.Context) error {
+	st, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if st.Dirty {
+		return fmt.Errorf("version %d: %w", st.Version, ErrSchemaDirty)
+	}
+	if len(st.Pending) > 0 {
+		return fmt.Errorf("version %d, latest %d: %w", st.Version, st.Latest, ErrSchemaOutdated)
+	}
+	return nil
+}
+
+// Up применяет все неприменённые миграции и возвращает их число.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	n := 0
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		st, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		if st.Dirty {
+			return fmt.Errorf("version %d: %w", st.Version, ErrSchemaDirty)
+		}
+		for _, mg := range st.Pending {
+			if err := m.apply(ctx, conn, mg.Version, mg.Up, int64(mg.Version)); err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", mg.Version, mg.Name, err)
+			}
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// Down откатывает steps последних применённых миграций (steps <= 0 — все).
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	n := 0
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		st, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		if st.Dirty {
+			return fmt.Errorf("version %d: %w", st.Version, ErrSchemaDirty)
+		}
+		for i := len(m.migrations) - 1; i >= 0 && (steps <= 0 || n < steps); i-- {
+			mg := m.migrations[i]
+			if mg.Version > st.Version {
+				continue
+			}
+			if mg.Down == "" {
+				return fmt.Errorf("migration %d_%s: no down file", mg.Version, mg.Name)
+			}
+			target := int64(-1)
+			if i > 0 {
+				target = int64(m.migrations[i-1].Version)
+			}
+			if err := m.apply(ctx, conn, mg.Version, mg.Down, target); err != nil {
+				return fmt.Errorf("migration %d_%s down: %w", mg.Version, mg.Name, err)
+			}
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// Force записывает версию без выполнения миграций и снимает флаг dirty —
+// после ручного исправления упавшей миграции. version 0 — миграций нет.
+func (m *Migrator) Force(ctx context.Context, version uint64) error {
+	return m.locked(ctx, func(conn *pgxpool.Conn) error {
+		v := int64(version)
+		if version == 0 {
+			v = -1
+		}
+		return setVersion(ctx, conn, v, false)
+	})
+}
+
+// apply выполняет миграцию как golang-migrate: сначала версия target помечается
+// dirty, затем выполняется файл целиком, затем флаг снимается.
+func (m *Migrator) apply(ctx context.Context, conn *pgxpool.Conn, version uint64, sql string, target int64) error {
+	if err := setVersion(ctx, conn, target, true); err != nil {
+		return err
+	}
+	// без аргументов pgx идёт по простому протоколу: в файле может быть несколько запросов
+	if _, err := conn.Exec(ctx, sql); err != nil {
+		return err
+	}
+	return setVersion(ctx, conn, target, false)
+}
+
+// setVersion — как у golang-migrate: в таблице одна строка; версия -1 без dirty — пустая таблица.
+func setVersion(ctx context.Context, conn *pgxpool.Conn, version int64, dirty bool) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `TRUNCATE schema_migrations`); err != nil {
+			return err
+		}
+		if version >= 0 || dirty {
+			_, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)`, version, dirty)
+			return err
+		}
+		return nil
+	})
+}
+
+// locked выполняет fn под advisory-блокировкой на отдельном соединении: две реплики
+// (или реплика и migrate CLI) не накатывают миграции одновременно.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint NOT NULL PRIMARY KEY,
+		dirty boolean NOT NULL)`); err != nil {
+		return err
+	}
+	key, err := migrateLockKey(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, key); err != nil {
+		return err
+	}
+	defer func() {
+		// ctx к этому моменту может быть отменён
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, key); err != nil {
+			conn.Conn().Close(context.Background())
+		}
+	}()
+	return fn(conn)
+}
+
+// migrateLockKey — тот же ключ, что берёт golang-migrate для postgres:
+// crc32 от "<схема>\x00<таблица>\x00<база>", умноженный на его соль.
+func migrateLockKey(ctx context.Context, conn *pgxpool.Conn) (int64, error) {
+	var dbName, schema string
+	if err := conn.QueryRow(ctx, `SELECT current_database(), current_schema()`).Scan(&dbName, &schema); err != nil {
+		return 0, err
+	}
+	const salt uint32 = 1486364155
+	sum := crc32.ChecksumIEEE([]byte(schema + "\x00schema_migrations\x00" + dbName))
+	return int64(sum * salt), nil
+}
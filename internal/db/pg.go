@@ -15,6 +15,7 @@ func MustOpen(dsn string) *pgxpool.Pool {
 	cfg.MaxConns = 10
 	cfg.MinConns = 1
 	cfg.MaxConnLifetime = time.Hour
+	cfg.ConnConfig.Tracer = queryTracer{}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	pool, err := pgxpool.NewWithConfig(ctx, cfg)
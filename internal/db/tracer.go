@@ -0,0 +1,28 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"auth/internal/platform/tracing"
+)
+
+// queryTracer — спаны OpenTelemetry на каждый запрос pgx (pgx.QueryTracer).
+type queryTracer struct{}
+
+func (queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, _ = tracing.Start(ctx, "db.query",
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.statement", data.SQL),
+	)
+	return ctx
+}
+
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	tracing.End(span, data.Err)
+}
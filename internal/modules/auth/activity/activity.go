@@ -0,0 +1,130 @@
+// Package activity отмечает активность сессий: копит отметки в памяти
+// и пишет их в БД пачками, чтобы не делать UPDATE на каждый запрос.
+package activity
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"auth/internal/modules/auth/domain"
+	"auth/internal/platform/clock"
+)
+
+// при таком размере очереди сбрасываем её, не дожидаясь тикера
+const maxBatch = 1000
+
+// сколько ждём запись последней пачки при остановке
+const finalFlushTimeout = 5 * time.Second
+
+type mark struct {
+	at time.Time
+	ip string
+}
+
+// Tracker — write-behind буфер для sessions.last_active.
+type Tracker struct {
+	sessions   domain.SessionRepo
+	flushEvery time.Duration // <= 0 — отметки выключены
+	throttle   time.Duration // не чаще одной отметки на сессию за этот период
+	clock      clock.Clock
+
+	mu      sync.Mutex
+	seen    map[string]mark // sid -> последняя поставленная в очередь отметка
+	pending map[string]domain.SessionTouch
+	kick    chan struct{}
+}
+
+func NewTracker(sessions domain.SessionRepo, flushEvery, throttle time.Duration, clk clock.Clock) *Tracker {
+	return &Tracker{
+		sessions:   sessions,
+		flushEvery: flushEvery,
+		throttle:   throttle,
+		clock:      clk,
+		seen:       map[string]mark{},
+		pending:    map[string]domain.SessionTouch{},
+		kick:       make(chan struct{}, 1),
+	}
+}
+
+// Touch отмечает запрос в рамках сессии. Повторные отметки в пределах throttle
+// пропускаются, если не сменился IP.
+func (t *Tracker) Touch(sessionID, ip string) {
+	// без Run буфер никто не сбрасывает — не копим его
+	if sessionID == "" || t.flushEvery <= 0 {
+		return
+	}
+	now := t.clock.Now().UTC()
+
+	t.mu.Lock()
+	if m, ok := t.seen[sessionID]; ok && m.ip == ip && now.Sub(m.at) < t.throttle {
+		t.mu.Unlock()
+		return
+	}
+	t.seen[sessionID] = mark{at: now, ip: ip}
+	t.pending[sessionID] = domain.SessionTouch{SessionID: sessionID, IP: ip, At: now}
+	full := len(t.pending) >= maxBatch
+	t.mu.Unlock()
+
+	if full {
+		select {
+		case t.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Run периодически сбрасывает буфер; при отмене ctx сбрасывает остаток и выходит.
+func (t *Tracker) Run(ctx context.Context) {
+	if t.flushEvery <= 0 {
+		return
+	}
+	tick := time.NewTicker(t.flushEvery)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// последнюю пачку пишем уже после отмены ctx, но не дольше finalFlushTimeout
+			fctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), finalFlushTimeout)
+			t.Flush(fctx)
+			cancel()
+			return
+		case <-tick.C:
+		case <-t.kick:
+		}
+		t.Flush(ctx)
+	}
+}
+
+// Flush пишет накопленные отметки одним запросом. Отметки — best effort:
+// при ошибке пачка теряется, следующий запрос клиента поставит новую.
+func (t *Tracker) Flush(ctx context.Context) {
+	t.mu.Lock()
+	if len(t.pending) == 0 {
+		t.pruneSeen()
+		t.mu.Unlock()
+		return
+	}
+	batch := make([]domain.SessionTouch, 0, len(t.pending))
+	for _, p := range t.pending {
+		batch = append(batch, p)
+	}
+	t.pending = map[string]domain.SessionTouch{}
+	t.pruneSeen()
+	t.mu.Unlock()
+
+	if err := t.sessions.Touch(ctx, batch); err != nil {
+		slog.Error("flush session activity", "sessions", len(batch), "err", err)
+	}
+}
+
+// pruneSeen забывает сессии, которые давно не присылали запросов. Вызывать под mu.
+func (t *Tracker) pruneSeen() {
+	cutoff := t.clock.Now().Add(-t.throttle)
+	for sid, m := range t.seen {
+		if m.at.Before(cutoff) {
+			delete(t.seen, sid)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type AuditEvent struct {
+	ID        int64
+	UserID    *string
+	Action    string
+	IPAddress *string
+	UserAgent *string
+	Payload   map[string]any
+	CreatedAt time.Time
+}
+
+type AuditRepo interface {
+	Record(ctx context.Context, e AuditEvent) error
+	ListByUser(ctx context.Context, userID string) ([]AuditEvent, error)
+	// ListByAction — до limit последних событий с таким действием, в том числе
+	// обезличенных (UserID == nil); новые первыми.
+	ListByAction(ctx context.Context, action string, limit int) ([]AuditEvent, error)
+}
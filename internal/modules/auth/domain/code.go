@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 type CodeKind string
 
@@ -8,6 +11,8 @@ const (
 	CodeSignup CodeKind = "signup"
 	Code2FA    CodeKind = "twofa"
 	CodeReset  CodeKind = "reset"
+	CodeReauth CodeKind = "reauth" // подтверждение действий для аккаунтов без пароля
+	CodeNotMe  CodeKind = "not_me" // одноразовый nonce ссылки «это был не я»
 )
 
 type VerificationCode struct {
@@ -22,7 +27,10 @@ type VerificationCode struct {
 }
 
 type CodeRepo interface {
-	Save(c VerificationCode) error
-	Consume(userID string, kind CodeKind, code string) (*VerificationCode, error)
-	ResendAllowed(userID string, kind CodeKind) (bool, error)
+	Save(ctx context.Context, c VerificationCode) error
+	Consume(ctx context.Context, userID string, kind CodeKind, code string) (*VerificationCode, error)
+	ResendAllowed(ctx context.Context, userID string, kind CodeKind) (bool, error)
+	ListByUser(ctx context.Context, userID string) ([]VerificationCode, error)
+	// DeleteExpired удаляет до limit кодов, истёкших или использованных раньше before.
+	DeleteExpired(ctx context.Context, before time.Time, limit int) (int, error)
 }
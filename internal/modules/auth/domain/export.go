@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// DataExport — собранная выгрузка персональных данных. Хранится в БД, а не на
+// диске, чтобы ссылку из письма могла обслужить любая реплика.
+type DataExport struct {
+	ID        string
+	UserID    string
+	Data      []byte // JSON-архив
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type ExportRepo interface {
+	Save(ctx context.Context, e DataExport) error
+	Get(ctx context.Context, id string) (*DataExport, error)
+	// DeleteExpired удаляет до limit выгрузок, истёкших раньше before.
+	DeleteExpired(ctx context.Context, before time.Time, limit int) (int, error)
+}
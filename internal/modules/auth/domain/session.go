@@ -1,6 +1,15 @@
 package domain
 
-import "time"
+import (
+	"context"
+	"net/http"
+	"time"
+
+	apperrors "auth/internal/platform/errors"
+)
+
+// ErrSessionLimit — достигнут лимит активных сессий, а политика — отказывать во входе.
+var ErrSessionLimit = apperrors.New(http.StatusConflict, "SESSION_LIMIT_EXCEEDED")
 
 type Session struct {
 	ID               string
@@ -14,15 +23,143 @@ type Session struct {
 	RevokedAt        *time.Time
 
 	ExpiresAt time.Time // <-- ДОБАВИЛИ
+
+	DeviceID    *string // стабильный id устройства от клиента
+	Fingerprint *string // см. useragent.Fingerprint
+
+	// Местоположение по IP на момент входа (если настроена геобаза)
+	City    *string
+	Country *string
+
+	RememberMe bool // при входе выбрали «запомнить меня» — длинный срок жизни
+}
+
+// SessionPolicy — сроки жизни сессий.
+type SessionPolicy struct {
+	TTL         time.Duration // обычная сессия; каждый refresh продлевает её на этот срок
+	RememberTTL time.Duration // то же для «запомнить меня»
+	MaxLifetime time.Duration // абсолютный предел от входа, refresh дальше не продлевает (0 — без предела)
+	IdleTimeout time.Duration // сессия без активности дольше этого считается истёкшей (0 — не проверяем)
+
+	MaxActive       int          // лимит активных сессий пользователя (0 — без лимита)
+	MaxActiveByRole map[Role]int // переопределение лимита для отдельных ролей
+	EvictOnLimit    bool         // при превышении завершать самую давно активную сессию вместо отказа
+}
+
+// SessionLimit — ограничение числа активных сессий для CreateWithLimit.
+type SessionLimit struct {
+	Max         int       // 0 — без лимита
+	Evict       bool      // false — новую сессию не создаём (ErrSessionLimit)
+	ActiveSince time.Time // сессии с last_active не позже этого момента брошены и в лимит не входят; нулевое — без отсечки
+}
+
+// Limit возвращает лимит сессий для роли на момент now.
+func (p SessionPolicy) Limit(role Role, now time.Time) SessionLimit {
+	max := p.MaxActive
+	if n, ok := p.MaxActiveByRole[role]; ok {
+		max = n
+	}
+	return SessionLimit{Max: max, Evict: p.EvictOnLimit, ActiveSince: p.ActiveSince(now)}
+}
+
+func DefaultSessionPolicy() SessionPolicy {
+	return SessionPolicy{
+		TTL:         24 * time.Hour,
+		RememberTTL: 30 * 24 * time.Hour,
+		MaxLifetime: 90 * 24 * time.Hour,
+		IdleTimeout: 14 * 24 * time.Hour,
+
+		MaxActive:    10,
+		EvictOnLimit: true,
+	}
+}
+
+// ExpiresAt — срок действия сессии, созданной в createdAt, после входа или refresh в момент now.
+func (p SessionPolicy) ExpiresAt(createdAt, now time.Time, remember bool) time.Time {
+	ttl := p.TTL
+	if remember {
+		ttl = p.RememberTTL
+	}
+	exp := now.Add(ttl)
+	if p.MaxLifetime > 0 {
+		if limit := createdAt.Add(p.MaxLifetime); limit.Before(exp) {
+			exp = limit
+		}
+	}
+	return exp
+}
+
+// ActiveSince — last_active, раньше которого сессия считается брошенной
+// (нулевое время, если простой не ограничен).
+func (p SessionPolicy) ActiveSince(now time.Time) time.Time {
+	if p.IdleTimeout <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-p.IdleTimeout)
+}
+
+// Active — сессия не отозвана, не истекла и не простаивала дольше IdleTimeout.
+func (p SessionPolicy) Active(s Session, now time.Time) bool {
+	if !s.Active(now) {
+		return false
+	}
+	since := p.ActiveSince(now)
+	return since.IsZero() || s.LastActive.After(since)
+}
+
+// SessionTouch — отметка активности сессии для пакетного обновления last_active.
+type SessionTouch struct {
+	SessionID string
+	IP        string
+	At        time.Time
+}
+
+// SessionSort — порядок выдачи сессий в ListByUser.
+type SessionSort string
+
+const (
+	SortByCreated    SessionSort = "created_at"  // сначала новые (по умолчанию)
+	SortByLastActive SessionSort = "last_active" // сначала недавно активные
+)
+
+// SessionFilter — параметры выборки сессий пользователя.
+type SessionFilter struct {
+	ActiveOnly  bool      // только не отозванные и не истёкшие
+	ActiveSince time.Time // вместе с ActiveOnly: только с last_active позже этого момента
+	Sort        SessionSort
+}
+
+// Active — сессия не отозвана и не истекла на момент now.
+func (s Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && (s.ExpiresAt.IsZero() || s.ExpiresAt.After(now))
 }
 
 type SessionRepo interface {
-	Create(s Session) (*Session, error)
-	ListByUser(userID string, page, limit int) ([]Session, int, error)
-	Revoke(sessionID, userID string) error
-	RevokeOthers(currentSessionID, userID string) (int, error)
-	RevokeCurrent(sessionID, userID string) error
-	RevokeAll(userID string) (int, error)
-
-	FindByRefreshHash(hash string) (*Session, error) // <-- ДОБАВИЛИ
+	Create(ctx context.Context, s Session) (*Session, error)
+	// CreateWithLimit создаёт сессию с учётом лимита активных сессий пользователя:
+	// вытесняет самые давно активные (limit.Evict) или возвращает ErrSessionLimit.
+	CreateWithLimit(ctx context.Context, s Session, limit SessionLimit) (*Session, error)
+	ListByUser(ctx context.Context, userID string, f SessionFilter, page, limit int) ([]Session, int, error)
+	// Revoke отзывает сессию пользователя (повторно — без изменений);
+	// ErrNotFound, если у пользователя нет такой сессии.
+	Revoke(ctx context.Context, sessionID, userID string) error
+	RevokeOthers(ctx context.Context, currentSessionID, userID string) (int, error)
+	RevokeCurrent(ctx context.Context, sessionID, userID string) error
+	RevokeAll(ctx context.Context, userID string) (int, error)
+
+	FindByRefreshHash(ctx context.Context, hash string) (*Session, error) // <-- ДОБАВИЛИ
+
+	// Rotate заменяет refresh-токен сессии, продлевает её и отмечает активность. false — если
+	// сессию уже отозвали или токен успели обновить параллельным запросом.
+	Rotate(ctx context.Context, sessionID, oldHash, newHash, ip string, expiresAt time.Time) (bool, error)
+	// Touch пакетно обновляет last_active и последний IP у активных сессий.
+	Touch(ctx context.Context, batch []SessionTouch) error
+
+	// KnownDevice — true, если у пользователя уже была сессия с таким отпечатком
+	// или сессий не было вовсе (первый вход — не повод для тревоги). История
+	// устройств хранится отдельно и не теряется при очистке сессий (DeleteExpired).
+	KnownDevice(ctx context.Context, userID, fingerprint string) (bool, error)
+
+	// DeleteExpired удаляет до limit сессий, истёкших или отозванных раньше before.
+	DeleteExpired(ctx context.Context, before time.Time, limit int) (int, error)
 }
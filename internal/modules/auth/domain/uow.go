@@ -0,0 +1,19 @@
+package domain
+
+import "context"
+
+// Repos — репозитории, работающие в одной транзакции.
+type Repos struct {
+	Users    UserRepo
+	Codes    CodeRepo
+	Sessions SessionRepo
+	Audit    AuditRepo
+	Exports  ExportRepo
+}
+
+// UnitOfWork выполняет многошаговые сценарии атомарно.
+type UnitOfWork interface {
+	// Do вызывает fn с репозиториями внутри транзакции: если fn вернула ошибку,
+	// все изменения откатываются и Do возвращает её как есть, иначе — commit.
+	Do(ctx context.Context, fn func(r Repos) error) error
+}
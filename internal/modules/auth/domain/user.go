@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 type Role string
 
@@ -25,6 +28,16 @@ type User struct {
 	UpdatedAt      time.Time
 	Providers      []string
 	TwoFAEnabled   bool
+
+	// Мягкое удаление: до PurgeAfter аккаунт можно восстановить входом
+	DeletedAt  *time.Time
+	PurgeAfter *time.Time
+
+	// Вход по паролю заблокирован до сброса пароля (например, после «это был не я»)
+	PasswordResetRequired bool
+
+	// Язык ответов API; пусто — по Accept-Language. Письма пока только на русском
+	Locale string
 }
 
 type CreateUserParams struct {
@@ -37,15 +50,27 @@ type CreateUserParams struct {
 }
 
 type UserRepo interface {
-	Create(u CreateUserParams) (*User, error)
-	GetByEmail(email string) (*User, error)
-	ExistsByEmail(email string) (bool, error)
-	ConfirmEmail(userID string) error
-	UpdatePassword(userID string, newHash string) error
+	Create(ctx context.Context, u CreateUserParams) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	ExistsByEmail(ctx context.Context, email string) (bool, error)
+	ConfirmEmail(ctx context.Context, userID string) error
+	UpdatePassword(ctx context.Context, userID string, newHash string) error     // снимает PasswordResetRequired
+	UpdatePasswordHash(ctx context.Context, userID string, newHash string) error // только хеш, флаги не трогает
+	RequirePasswordReset(ctx context.Context, userID string) error
 
 	// НОВОЕ:
-	GetByID(id string) (*User, error)
-	UpdateProfile(userID string, firstName *string, lastName *string, phone *string) error
-	Delete(id string) error
-	SetTwoFA(userID string, enabled bool) error
+	GetByID(ctx context.Context, id string) (*User, error)
+	UpdateProfile(ctx context.Context, userID string, firstName *string, lastName *string, phone *string) error
+	Delete(ctx context.Context, id string) error
+	SetTwoFA(ctx context.Context, userID string, enabled bool) error
+	SetLocale(ctx context.Context, userID, locale string) error
+	// LinkProvider добавляет OAuth-провайдера в User.Providers (повторно — без изменений).
+	LinkProvider(ctx context.Context, userID, provider string) error
+
+	// Мягкое удаление
+	ScheduleDeletion(ctx context.Context, userID string, purgeAfter time.Time) error
+	Restore(ctx context.Context, userID string) error
+	// PurgeDeleted окончательно удаляет до limit аккаунтов с истёкшим сроком
+	// восстановления (purge_after <= before) и обезличивает их записи аудита.
+	PurgeDeleted(ctx context.Context, before time.Time, limit int) (int, error)
 }
@@ -0,0 +1,267 @@
+// Package export собирает выгрузку персональных данных пользователя.
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"auth/internal/modules/auth/domain"
+	"auth/internal/platform/clock"
+	apperrors "auth/internal/platform/errors"
+	"auth/internal/platform/notify"
+	"auth/internal/platform/security"
+)
+
+var (
+	ErrInProgress = apperrors.New(http.StatusTooManyRequests, "EXPORT_IN_PROGRESS")
+	ErrNotFound   = apperrors.ErrNotFound.WithMessageID("link_invalid")
+)
+
+// Service асинхронно собирает JSON-архив с данными пользователя, сохраняет его
+// в БД (domain.ExportRepo) и присылает на email подписанную ссылку для скачивания.
+type Service struct {
+	users    domain.UserRepo
+	sessions domain.SessionRepo
+	codes    domain.CodeRepo
+	audit    domain.AuditRepo
+	exports  domain.ExportRepo
+	mailer   notify.Sender
+	signer   *security.Signer
+	clock    clock.Clock
+
+	baseURL string        // публичный адрес API для ссылки в письме
+	linkTTL time.Duration // сколько живёт ссылка (и сам архив)
+
+	// выгрузки в процессе на этой реплике: повторный запрос на другую реплику
+	// соберёт вторую выгрузку, что безвредно
+	mu      sync.Mutex
+	running map[string]bool // userID -> выгрузка в процессе
+}
+
+func NewService(
+	users domain.UserRepo,
+	sessions domain.SessionRepo,
+	codes domain.CodeRepo,
+	audit domain.AuditRepo,
+	exports domain.ExportRepo,
+	mailer notify.Sender,
+	signer *security.Signer,
+	clk clock.Clock,
+	baseURL string,
+	linkTTL time.Duration,
+) *Service {
+	return &Service{
+		users: users, sessions: sessions, codes: codes, audit: audit, exports: exports,
+		mailer: mailer, signer: signer, clock: clk,
+		baseURL: baseURL, linkTTL: linkTTL,
+		running: map[string]bool{},
+	}
+}
+
+// Request ставит выгрузку в работу и сразу возвращает её id. Сборка идёт в фоне
+// с контекстом запроса без его отмены и дедлайна: ответ клиенту уже отдан.
+func (s *Service) Request(ctx context.Context, userID string) (string, error) {
+	s.mu.Lock()
+	if s.running[userID] {
+		s.mu.Unlock()
+		return "", ErrInProgress
+	}
+	s.running[userID] = true
+	s.mu.Unlock()
+
+	id := uuid.New().String()
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer func() {
+			s.mu.Lock()
+			delete(s.running, userID)
+			s.mu.Unlock()
+		}()
+		if err := s.build(ctx, id, userID); err != nil {
+			slog.Error("data export", "export", id, "user_id", userID, "err", err)
+		}
+	}()
+	return id, nil
+}
+
+// Open проверяет подпись ссылки и возвращает JSON-архив выгрузки.
+func (s *Service) Open(ctx context.Context, id, exp, sig string) ([]byte, error) {
+	if _, err := uuid.Parse(id); err != nil || !s.signer.Verify(sig, exp, "export", id) {
+		return nil, ErrNotFound
+	}
+	e, err := s.exports.Get(ctx, id)
+	if errors.Is(err, apperrors.ErrNotFound) || (err == nil && !s.clock.Now().Before(e.ExpiresAt)) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e.Data, nil
+}
+
+type archive struct {
+	GeneratedAt   string         `json:"generated_at"`
+	Profile       profile        `json:"profile"`
+	Providers     []string       `json:"linked_providers"`
+	Sessions      []session      `json:"sessions"`
+	AuditEvents   []auditEvent   `json:"audit_events"`
+	Verifications []verification `json:"verification_history"`
+}
+
+type profile struct {
+	ID             string  `json:"id"`
+	Email          string  `json:"email"`
+	Phone          *string `json:"phone"`
+	FirstName      string  `json:"first_name"`
+	LastName       string  `json:"last_name"`
+	Role           string  `json:"role"`
+	EmailConfirmed bool    `json:"email_confirmed"`
+	PhoneConfirmed bool    `json:"phone_confirmed"`
+	TwoFAEnabled   bool    `json:"twofa_enabled"`
+	HasPassword    bool    `json:"has_password"`
+	Locale         string  `json:"locale"`
+	CreatedAt      string  `json:"created_at"`
+	UpdatedAt      string  `json:"updated_at"`
+	DeletedAt      *string `json:"deleted_at"`
+	PurgeAfter     *string `json:"purge_after"`
+}
+
+type session struct {
+	ID          string  `json:"id"`
+	DeviceName  *string `json:"device_name"`
+	DeviceID    *string `json:"device_id"`
+	Fingerprint *string `json:"fingerprint"`
+	IPAddress   *string `json:"ip_address"`
+	UserAgent   *string `json:"user_agent"`
+	City        *string `json:"city"`
+	Country     *string `json:"country"`
+	RememberMe  bool    `json:"remember_me"`
+	CreatedAt   string  `json:"created_at"`
+	LastActive  string  `json:"last_active"`
+	ExpiresAt   string  `json:"expires_at,omitempty"`
+	RevokedAt   *string `json:"revoked_at"`
+}
+
+type auditEvent struct {
+	Action    string         `json:"action"`
+	IPAddress *string        `json:"ip_address"`
+	UserAgent *string        `json:"user_agent"`
+	Payload   map[string]any `json:"payload,omitempty"`
+	CreatedAt string         `json:"created_at"`
+}
+
+// сами коды не выгружаем — только историю отправки
+type verification struct {
+	Kind       string  `json:"kind"`
+	SentTo     string  `json:"sent_to"`
+	CreatedAt  string  `json:"created_at"`
+	ExpiresAt  string  `json:"expires_at"`
+	ConsumedAt *string `json:"consumed_at"`
+}
+
+func (s *Service) build(ctx context.Context, id, userID string) error {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("load user: %w", err)
+	}
+
+	a := archive{
+		GeneratedAt: formatTime(s.clock.Now()),
+		Profile: profile{
+			ID: u.ID, Email: u.Email, Phone: u.Phone,
+			FirstName: u.FirstName, LastName: u.LastName, Role: string(u.Role),
+			EmailConfirmed: u.EmailConfirmed, PhoneConfirmed: u.PhoneConfirmed,
+			TwoFAEnabled: u.TwoFAEnabled, HasPassword: u.PasswordHash != nil, Locale: u.Locale,
+			CreatedAt: formatTime(u.CreatedAt), UpdatedAt: formatTime(u.UpdatedAt),
+			DeletedAt: formatTimePtr(u.DeletedAt), PurgeAfter: formatTimePtr(u.PurgeAfter),
+		},
+		Providers:     append([]string{}, u.Providers...),
+		Sessions:      []session{},
+		AuditEvents:   []auditEvent{},
+		Verifications: []verification{},
+	}
+
+	const pageSize = 100
+	for page := 1; ; page++ {
+		items, total, err := s.sessions.ListByUser(ctx, userID, domain.SessionFilter{}, page, pageSize)
+		if err != nil {
+			return fmt.Errorf("list sessions: %w", err)
+		}
+		for _, ss := range items {
+			out := session{
+				ID: ss.ID, DeviceName: ss.DeviceName, DeviceID: ss.DeviceID, Fingerprint: ss.Fingerprint,
+				IPAddress: ss.IPAddress, UserAgent: ss.UserAgent, City: ss.City, Country: ss.Country,
+				RememberMe: ss.RememberMe,
+				CreatedAt:  formatTime(ss.CreatedAt), LastActive: formatTime(ss.LastActive),
+				RevokedAt: formatTimePtr(ss.RevokedAt),
+			}
+			if !ss.ExpiresAt.IsZero() {
+				out.ExpiresAt = formatTime(ss.ExpiresAt)
+			}
+			a.Sessions = append(a.Sessions, out)
+		}
+		if len(items) == 0 || page*pageSize >= total {
+			break
+		}
+	}
+
+	events, err := s.audit.ListByUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("list audit events: %w", err)
+	}
+	for _, e := range events {
+		a.AuditEvents = append(a.AuditEvents, auditEvent{
+			Action: e.Action, IPAddress: e.IPAddress, UserAgent: e.UserAgent,
+			Payload: e.Payload, CreatedAt: formatTime(e.CreatedAt),
+		})
+	}
+
+	codes, err := s.codes.ListByUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("list verification codes: %w", err)
+	}
+	for _, c := range codes {
+		a.Verifications = append(a.Verifications, verification{
+			Kind: string(c.Kind), SentTo: c.SentTo,
+			CreatedAt: formatTime(c.CreatedAt), ExpiresAt: formatTime(c.ExpiresAt),
+			ConsumedAt: formatTimePtr(c.ConsumedAt),
+		})
+	}
+
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	exp := s.clock.Now().Add(s.linkTTL)
+	if err := s.exports.Save(ctx, domain.DataExport{ID: id, UserID: userID, Data: data, ExpiresAt: exp}); err != nil {
+		return fmt.Errorf("save export: %w", err)
+	}
+
+	link := fmt.Sprintf("%s/api/v1/user/export/%s/download?expires=%d&sig=%s",
+		s.baseURL, id, exp.Unix(), url.QueryEscape(s.signer.Sign(exp, "export", id)))
+	if s.mailer != nil {
+		if err := s.mailer.SendExportReady(ctx, u.Email, link, exp); err != nil {
+			return fmt.Errorf("send export email: %w", err)
+		}
+	}
+	return nil
+}
+
+func formatTime(t time.Time) string { return t.UTC().Format(time.RFC3339) }
+
+func formatTimePtr(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := formatTime(*t)
+	return &s
+}
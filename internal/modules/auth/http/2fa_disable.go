@@ -4,6 +4,8 @@ import (
 	"github.com/gofiber/fiber/v2"
 
 	"auth/internal/modules/auth/domain"
+	apperrors "auth/internal/platform/errors"
+	"auth/internal/platform/i18n"
 	"auth/internal/platform/security"
 )
 
@@ -15,43 +17,28 @@ func Disable2FAHandler(userRepo domain.UserRepo) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		uid, _ := c.Locals("user_id").(string)
 		if uid == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error_code": "UNAUTHORIZED",
-				"message":    "Требуется авторизация",
-			})
+			return apperrors.ErrUnauthorized
 		}
 
 		var req disable2FAReq
 		if err := c.BodyParser(&req); err != nil || req.Password == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_FIELDS",
-				"message":    "Пароль обязателен",
-			})
+			return apperrors.ErrInvalidFields.WithMessageID("password_required")
 		}
 
-		u, err := userRepo.GetByID(uid)
+		u, err := userRepo.GetByID(c.UserContext(), uid)
 		if err != nil || u == nil || u.PasswordHash == nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_STATE",
-				"message":    "Невозможно отключить 2FA",
-			})
+			return errInvalidState.WithMessageID("twofa_disable_unavailable")
 		}
 
-		ok, _ := security.CheckPassword(*u.PasswordHash, req.Password)
+		ok, _, _ := security.CheckPassword(*u.PasswordHash, req.Password)
 		if !ok {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_PASSWORD",
-				"message":    "Неверный пароль",
-			})
+			return errInvalidPassword
 		}
 
-		if err := userRepo.SetTwoFA(uid, false); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR",
-				"message":    "Не удалось отключить 2FA",
-			})
+		if err := userRepo.SetTwoFA(c.UserContext(), uid, false); err != nil {
+			return apperrors.ErrInternal.WithMessageID("twofa_disable_failed").Wrap(err)
 		}
 
-		return c.JSON(fiber.Map{"message": "2FA отключена"})
+		return c.JSON(fiber.Map{"message": i18n.Msg(c, "twofa_disabled")})
 	}
 }
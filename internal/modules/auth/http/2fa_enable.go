@@ -2,6 +2,8 @@ package http
 
 import (
 	"auth/internal/modules/auth/domain"
+	apperrors "auth/internal/platform/errors"
+	"auth/internal/platform/i18n"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -9,17 +11,11 @@ func Enable2FAHandler(userRepo domain.UserRepo) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		uid, _ := c.Locals("user_id").(string)
 		if uid == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error_code": "UNAUTHORIZED",
-				"message":    "Требуется авторизация",
-			})
+			return apperrors.ErrUnauthorized
 		}
-		if err := userRepo.SetTwoFA(uid, true); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR",
-				"message":    "Не удалось включить 2FA",
-			})
+		if err := userRepo.SetTwoFA(c.UserContext(), uid, true); err != nil {
+			return apperrors.ErrInternal.WithMessageID("twofa_enable_failed").Wrap(err)
 		}
-		return c.JSON(fiber.Map{"message": "2FA включена"})
+		return c.JSON(fiber.Map{"message": i18n.Msg(c, "twofa_enabled")})
 	}
 }
@@ -0,0 +1,18 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"auth/internal/modules/auth/activity"
+)
+
+// SessionActivity отмечает активность текущей сессии. Ставится после JWTAuth:
+// берёт sid из токена, запись в БД идёт пачками через activity.Tracker.
+func SessionActivity(tracker *activity.Tracker) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if sid, _ := c.Locals("session_id").(string); sid != "" {
+			tracker.Touch(sid, c.IP())
+		}
+		return c.Next()
+	}
+}
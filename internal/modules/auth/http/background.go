@@ -0,0 +1,52 @@
+package http
+
+import (
+	"context"
+
+	"auth/internal/platform/scheduler"
+)
+
+// Start запускает фоновые задачи модуля, которые нужны на каждой реплике;
+// они останавливаются при отмене ctx. Периодическая очистка — в Jobs.
+func (m *Module) Start(ctx context.Context) {
+	m.bg.Add(1)
+	go func() {
+		defer m.bg.Done()
+		m.activityTracker().Run(ctx)
+	}()
+}
+
+// Wait ждёт остановки фоновых задач после отмены ctx, переданного в Start
+// (в том числе сброса накопленных отметок активности).
+func (m *Module) Wait() { m.bg.Wait() }
+
+// Jobs — периодические задачи модуля для планировщика.
+func (m *Module) Jobs() []scheduler.Job {
+	return []scheduler.Job{
+		{
+			// аккаунты, у которых истёк срок восстановления
+			Name: "auth.purge_deleted_accounts", Interval: m.purgeInterval, Singleton: true,
+			Run: scheduler.Batches(m.cleanupBatch, func(ctx context.Context, limit int) (int, error) {
+				return m.userRepo.PurgeDeleted(ctx, m.clock.Now(), limit)
+			}),
+		},
+		{
+			Name: "auth.prune_codes", Interval: m.cleanupInterval, Singleton: true,
+			Run: scheduler.Batches(m.cleanupBatch, func(ctx context.Context, limit int) (int, error) {
+				return m.codeRepo.DeleteExpired(ctx, m.clock.Now().Add(-m.codeRetention), limit)
+			}),
+		},
+		{
+			Name: "auth.prune_sessions", Interval: m.cleanupInterval, Singleton: true,
+			Run: scheduler.Batches(m.cleanupBatch, func(ctx context.Context, limit int) (int, error) {
+				return m.sessionRepo.DeleteExpired(ctx, m.clock.Now().Add(-m.sessionRetention), limit)
+			}),
+		},
+		{
+			Name: "auth.prune_exports", Interval: m.cleanupInterval, Singleton: true,
+			Run: scheduler.Batches(m.cleanupBatch, func(ctx context.Context, limit int) (int, error) {
+				return m.exportRepo.DeleteExpired(ctx, m.clock.Now(), limit)
+			}),
+		},
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-func DebugSendMailHandler(mailer *notify.Mailer) fiber.Handler {
+func DebugSendMailHandler(mailer notify.Sender) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		to := c.Query("to")
 		if to == "" {
@@ -17,7 +17,7 @@ func DebugSendMailHandler(mailer *notify.Mailer) fiber.Handler {
 				"error": "mailer is nil",
 			})
 		}
-		if err := mailer.SendSignupCode(c.Context(), to, "123456"); err != nil {
+		if err := mailer.SendSignupCode(c.UserContext(), to, "123456"); err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": err.Error(),
 			})
@@ -4,34 +4,27 @@ import (
 	"github.com/gofiber/fiber/v2"
 
 	"auth/internal/modules/auth/domain"
+	apperrors "auth/internal/platform/errors"
+	"auth/internal/platform/i18n"
 )
 
 func DeleteDeviceHandler(sessions domain.SessionRepo) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		uid, _ := c.Locals("user_id").(string)
 		if uid == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error_code": "UNAUTHORIZED",
-				"message":    "Требуется авторизация",
-			})
+			return apperrors.ErrUnauthorized
 		}
 
 		deviceID := c.Params("device_id")
 		if deviceID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_FIELDS",
-				"message":    "Нужно указать device_id",
-			})
+			return apperrors.ErrInvalidFields.WithMessageID("device_id_required")
 		}
 
-		if err := sessions.Revoke(deviceID, uid); err != nil {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error_code": "NOT_FOUND",
-				"message":    "Сессия не найдена",
-			})
+		if err := sessions.Revoke(c.UserContext(), deviceID, uid); err != nil {
+			return apperrors.ErrNotFound.WithMessageID("session_not_found")
 		}
 
-		return c.JSON(fiber.Map{"message": "Сессия успешно завершена"})
+		return c.JSON(fiber.Map{"message": i18n.Msg(c, "session_revoked")})
 	}
 }
 func DeleteOtherDevicesHandler(sessions domain.SessionRepo) fiber.Handler {
@@ -39,13 +32,11 @@ func DeleteOtherDevicesHandler(sessions domain.SessionRepo) fiber.Handler {
 		uid, _ := c.Locals("user_id").(string)
 		sid, _ := c.Locals("session_id").(string)
 		if uid == "" || sid == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error_code": "UNAUTHORIZED", "message": "Требуется авторизация",
-			})
+			return apperrors.ErrUnauthorized
 		}
-		count, _ := sessions.RevokeOthers(sid, uid)
+		count, _ := sessions.RevokeOthers(c.UserContext(), sid, uid)
 		return c.JSON(fiber.Map{
-			"message":             "Все остальные сессии завершены",
+			"message":             i18n.Msg(c, "other_sessions_revoked"),
 			"sessions_terminated": count,
 		})
 	}
@@ -56,15 +47,11 @@ func DeleteCurrentSessionHandler(sessions domain.SessionRepo) fiber.Handler {
 		uid, _ := c.Locals("user_id").(string)
 		sid, _ := c.Locals("session_id").(string)
 		if uid == "" || sid == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error_code": "UNAUTHORIZED", "message": "Требуется авторизация",
-			})
+			return apperrors.ErrUnauthorized
 		}
-		if err := sessions.RevokeCurrent(sid, uid); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR", "message": "Не удалось завершить сессию",
-			})
+		if err := sessions.RevokeCurrent(c.UserContext(), sid, uid); err != nil {
+			return apperrors.ErrInternal.WithMessageID("session_revoke_failed").Wrap(err)
 		}
-		return c.JSON(fiber.Map{"message": "Сессия успешно завершена"})
+		return c.JSON(fiber.Map{"message": i18n.Msg(c, "session_revoked")})
 	}
 }
@@ -7,14 +7,28 @@ import (
 	"github.com/gofiber/fiber/v2"
 
 	"auth/internal/modules/auth/domain"
+	"auth/internal/platform/clock"
+	apperrors "auth/internal/platform/errors"
+	"auth/internal/platform/geo"
+	"auth/internal/platform/useragent"
 )
 
 type deviceDTO struct {
-	ID         string  `json:"id"`
-	DeviceName *string `json:"device_name"`
-	LastActive string  `json:"last_active"`
-	IPAddress  *string `json:"ip_address"`
-	Location   *string `json:"location,omitempty"` // пока пусто
+	ID             string  `json:"id"`
+	DeviceName     *string `json:"device_name"`
+	Browser        string  `json:"browser"`
+	BrowserVersion string  `json:"browser_version,omitempty"`
+	OS             string  `json:"os"`
+	OSVersion      string  `json:"os_version,omitempty"`
+	DeviceType     string  `json:"device_type"` // desktop, mobile, tablet, bot, Other
+	IPAddress      *string `json:"ip_address"`
+	Location       *string `json:"location,omitempty"` // «Город, Страна»
+	CreatedAt      string  `json:"created_at"`
+	LastActive     string  `json:"last_active"`
+	ExpiresAt      string  `json:"expires_at"`
+	Revoked        bool    `json:"revoked"`
+	RevokedAt      *string `json:"revoked_at,omitempty"`
+	IsCurrent      bool    `json:"is_current"`
 }
 
 type devicesResp struct {
@@ -24,14 +38,29 @@ type devicesResp struct {
 	Limit   int         `json:"limit"`
 }
 
-func ListDevicesHandler(sessions domain.SessionRepo) fiber.Handler {
+func ListDevicesHandler(sessions domain.SessionRepo, locator geo.Locator, policy domain.SessionPolicy, clk clock.Clock) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		uid, _ := c.Locals("user_id").(string)
 		if uid == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error_code": "UNAUTHORIZED",
-				"message":    "Требуется авторизация",
-			})
+			return apperrors.ErrUnauthorized
+		}
+
+		sid, _ := c.Locals("session_id").(string)
+
+		var filter domain.SessionFilter
+		if v := c.Query("active"); v != "" {
+			active, err := strconv.ParseBool(v)
+			if err != nil {
+				return apperrors.ErrInvalidFields.WithMessageID("active_param_invalid")
+			}
+			filter.ActiveOnly = active
+			filter.ActiveSince = policy.ActiveSince(clk.Now())
+		}
+		switch sort := domain.SessionSort(c.Query("sort", string(domain.SortByCreated))); sort {
+		case domain.SortByCreated, domain.SortByLastActive:
+			filter.Sort = sort
+		default:
+			return apperrors.ErrInvalidFields.WithMessageID("sort_param_invalid")
 		}
 
 		page, _ := strconv.Atoi(c.Query("page", "1"))
@@ -43,27 +72,14 @@ func ListDevicesHandler(sessions domain.SessionRepo) fiber.Handler {
 			limit = 10
 		}
 
-		items, total, err := sessions.ListByUser(uid, page, limit)
+		items, total, err := sessions.ListByUser(c.UserContext(), uid, filter, page, limit)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR",
-				"message":    "Не удалось загрузить данные",
-			})
+			return apperrors.ErrInternal.WithMessageID("data_load_failed").Wrap(err)
 		}
 
 		out := make([]deviceDTO, 0, len(items))
 		for _, s := range items {
-			last := s.LastActive
-			if last.IsZero() {
-				last = s.CreatedAt
-			}
-			out = append(out, deviceDTO{
-				ID:         s.ID,
-				DeviceName: s.DeviceName,
-				LastActive: last.UTC().Format(time.RFC3339),
-				IPAddress:  s.IPAddress,
-				// Location — можно будет вычислять по IP позже
-			})
+			out = append(out, toDeviceDTO(s, sid, locator))
 		}
 
 		return c.JSON(devicesResp{
@@ -74,3 +90,56 @@ func ListDevicesHandler(sessions domain.SessionRepo) fiber.Handler {
 		})
 	}
 }
+
+func toDeviceDTO(s domain.Session, currentSID string, locator geo.Locator) deviceDTO {
+	last := s.LastActive
+	if last.IsZero() {
+		last = s.CreatedAt
+	}
+	var ua string
+	if s.UserAgent != nil {
+		ua = *s.UserAgent
+	}
+	info := useragent.Parse(ua)
+
+	d := deviceDTO{
+		ID:             s.ID,
+		DeviceName:     s.DeviceName,
+		Browser:        info.Browser,
+		BrowserVersion: info.BrowserVersion,
+		OS:             info.OS,
+		OSVersion:      info.OSVersion,
+		DeviceType:     info.DeviceType,
+		IPAddress:      s.IPAddress,
+		Location:       sessionLocation(s, locator),
+		CreatedAt:      s.CreatedAt.UTC().Format(time.RFC3339),
+		LastActive:     last.UTC().Format(time.RFC3339),
+		ExpiresAt:      s.ExpiresAt.UTC().Format(time.RFC3339),
+		Revoked:        s.RevokedAt != nil,
+		IsCurrent:      s.ID == currentSID,
+	}
+	if s.RevokedAt != nil {
+		at := s.RevokedAt.UTC().Format(time.RFC3339)
+		d.RevokedAt = &at
+	}
+	return d
+}
+
+// sessionLocation — местоположение, сохранённое при входе, а для старых сессий —
+// вычисленное по IP.
+func sessionLocation(s domain.Session, locator geo.Locator) *string {
+	loc := geo.Location{}
+	if s.City != nil {
+		loc.City = *s.City
+	}
+	if s.Country != nil {
+		loc.Country = *s.Country
+	}
+	if loc == (geo.Location{}) && s.IPAddress != nil {
+		loc, _ = locator.Lookup(*s.IPAddress)
+	}
+	if str := loc.String(); str != "" {
+		return &str
+	}
+	return nil
+}
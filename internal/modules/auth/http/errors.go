@@ -0,0 +1,20 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	apperrors "auth/internal/platform/errors"
+)
+
+// Ошибки модуля, которые возвращают несколько обработчиков.
+var (
+	errInvalidEmail       = apperrors.New(fiber.StatusBadRequest, "INVALID_EMAIL")
+	errInvalidCredentials = apperrors.New(fiber.StatusBadRequest, "INVALID_CREDENTIALS")
+	errInvalidPassword    = apperrors.New(fiber.StatusBadRequest, "INVALID_PASSWORD")
+	errInvalidState       = apperrors.New(fiber.StatusBadRequest, "INVALID_STATE")
+	errInvalidRefresh     = apperrors.New(fiber.StatusUnauthorized, "INVALID_REFRESH")
+	errRateLimited        = apperrors.New(fiber.StatusTooManyRequests, "RATE_LIMIT_EXCEEDED")
+	errMailSend           = apperrors.New(fiber.StatusInternalServerError, "MAIL_SEND_ERROR")
+	// после «это был не я» вход закрыт до сброса пароля
+	errPasswordResetRequired = apperrors.New(fiber.StatusForbidden, "PASSWORD_RESET_REQUIRED")
+)
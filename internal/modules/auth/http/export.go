@@ -0,0 +1,61 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"auth/internal/modules/auth/domain"
+	"auth/internal/modules/auth/export"
+	apperrors "auth/internal/platform/errors"
+	"auth/internal/platform/i18n"
+	"auth/internal/platform/logging"
+)
+
+// RequestExportHandler запускает сборку выгрузки персональных данных.
+// Архив собирается асинхронно, ссылка на скачивание приходит на email.
+func RequestExportHandler(exports *export.Service, audit domain.AuditRepo) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		uid, _ := c.Locals("user_id").(string)
+		if uid == "" {
+			return apperrors.ErrUnauthorized
+		}
+
+		id, err := exports.Request(c.UserContext(), uid)
+		if errors.Is(err, export.ErrInProgress) {
+			return err
+		}
+		if err != nil {
+			return apperrors.ErrInternal.WithMessageID("export_start_failed").Wrap(err)
+		}
+
+		ip, ua := c.IP(), c.Get("User-Agent")
+		if err := audit.Record(c.UserContext(), domain.AuditEvent{
+			UserID:    &uid,
+			Action:    "data_export_requested",
+			IPAddress: &ip,
+			UserAgent: &ua,
+			Payload:   map[string]any{"export_id": id},
+		}); err != nil {
+			logging.FromFiber(c).Error("audit data export", "export", id, "err", err)
+		}
+
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"message":   i18n.Msg(c, "export_started"),
+			"export_id": id,
+		})
+	}
+}
+
+// DownloadExportHandler отдаёт архив по подписанной ссылке из письма (без JWT).
+func DownloadExportHandler(exports *export.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		data, err := exports.Open(c.UserContext(), c.Params("export_id"), c.Query("expires"), c.Query("sig"))
+		if err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		c.Attachment("personal-data.json")
+		return c.Send(data)
+	}
+}
@@ -0,0 +1,282 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+
+	"auth/internal/modules/auth/domain"
+	authhttp "auth/internal/modules/auth/http"
+	"auth/internal/modules/auth/testkit"
+)
+
+const (
+	email       = "anna@example.com"
+	password    = "Otter-river-17"
+	newPassword = "Lantern-pine-42"
+)
+
+var (
+	laptop = map[string]any{"device_name": "Work laptop", "device_id": "device-1"}
+	phone  = map[string]any{"device_name": "Phone", "device_id": "device-2"}
+)
+
+func signIn(pw string, device map[string]any) map[string]any {
+	return merge(device, map[string]any{"email": email, "password": pw})
+}
+
+// TestAuthFlow — сквозной сценарий: регистрация → подтверждение → вход →
+// включение 2FA → вход с кодом → обновление токенов → сброс пароля.
+func TestAuthFlow(t *testing.T) {
+	k := testkit.New(t)
+	k.Record(t)
+
+	k.Step("sign up")
+	resp := k.Post(t, "/sign-up", map[string]any{
+		"email": email, "password": password, "first_name": "Anna", "last_name": "Smirnova",
+		"role": "journalist", "privacy_agreement": true,
+	})
+	wantStatus(t, resp, fiber.StatusCreated)
+	code := k.WaitMail(t, testkit.MailSignupCode, email).Code
+
+	k.Step("sign in before confirmation")
+	wantStatus(t, k.Post(t, "/sign-in", signIn(password, laptop)), fiber.StatusBadRequest)
+
+	k.Step("confirm email")
+	wantStatus(t, k.Post(t, "/sign-up/confirm", map[string]any{"email": email, "code": "000000"}), fiber.StatusBadRequest)
+	wantStatus(t, k.Post(t, "/sign-up/confirm", map[string]any{"email": email, "code": code}), fiber.StatusOK)
+
+	k.Step("sign in")
+	k.Clock.Advance(time.Minute)
+	resp = k.Post(t, "/sign-in", signIn(password, laptop))
+	wantStatus(t, resp, fiber.StatusOK)
+	access := resp.String(t, "access_token")
+
+	k.Step("enable 2FA")
+	resp = k.Do(t, testkit.Request{Method: fiber.MethodPost, Path: "/user/2fa/enable", Token: access})
+	wantStatus(t, resp, fiber.StatusOK)
+
+	k.Step("sign in with 2FA from a new device")
+	k.Clock.Advance(time.Hour)
+	resp = k.Post(t, "/sign-in", signIn(password, phone))
+	wantStatus(t, resp, fiber.StatusOK)
+	code = k.WaitMail(t, testkit.Mail2FACode, email).Code
+	wantStatus(t, k.Post(t, "/sign-in/2fa", merge(phone, map[string]any{"email": email, "code": "000000"})), fiber.StatusBadRequest)
+	resp = k.Post(t, "/sign-in/2fa", merge(phone, map[string]any{"email": email, "code": code}))
+	wantStatus(t, resp, fiber.StatusOK)
+	refresh := resp.String(t, "refresh_token")
+	k.WaitMail(t, testkit.MailNewDevice, email)
+
+	k.Step("refresh tokens")
+	k.Clock.Advance(20 * time.Minute)
+	resp = k.Post(t, "/refresh", map[string]any{"refresh_token": refresh})
+	wantStatus(t, resp, fiber.StatusOK)
+	rotated := resp.String(t, "refresh_token")
+	// старый refresh-токен после ротации не принимается
+	wantStatus(t, k.Post(t, "/refresh", map[string]any{"refresh_token": refresh}), fiber.StatusUnauthorized)
+
+	k.Step("reset password")
+	k.Clock.Advance(time.Hour)
+	wantStatus(t, k.Post(t, "/forgot-password", map[string]any{"email": email}), fiber.StatusOK)
+	code = k.LatestCode(t, email, domain.CodeReset)
+	resp = k.Post(t, "/reset-password", map[string]any{"email": email, "code": code, "new_password": newPassword})
+	wantStatus(t, resp, fiber.StatusOK)
+	// сброс завершает все сессии
+	wantStatus(t, k.Post(t, "/refresh", map[string]any{"refresh_token": rotated}), fiber.StatusUnauthorized)
+
+	k.Step("sign in with the new password")
+	wantStatus(t, k.Post(t, "/sign-in", signIn(password, phone)), fiber.StatusBadRequest)
+	resp = k.Post(t, "/sign-in", signIn(newPassword, phone))
+	wantStatus(t, resp, fiber.StatusOK)
+	k.WaitMail(t, testkit.Mail2FACode, email)
+
+	if left := k.Mail.All(); len(left) != 0 {
+		t.Errorf("unexpected mails: %+v", left)
+	}
+}
+
+// TestDataExport — архив выгрузки отдаётся по ссылке из письма, пока она не истекла.
+func TestDataExport(t *testing.T) {
+	k := testkit.New(t)
+	wantStatus(t, k.Post(t, "/sign-up", map[string]any{
+		"email": email, "password": password, "first_name": "Anna", "last_name": "Smirnova",
+		"role": "journalist", "privacy_agreement": true,
+	}), fiber.StatusCreated)
+	code := k.WaitMail(t, testkit.MailSignupCode, email).Code
+	wantStatus(t, k.Post(t, "/sign-up/confirm", map[string]any{"email": email, "code": code}), fiber.StatusOK)
+	resp := k.Post(t, "/sign-in", merge(signIn(password, laptop), map[string]any{"remember_me": true}))
+	wantStatus(t, resp, fiber.StatusOK)
+	access := resp.String(t, "access_token")
+	wantStatus(t, k.Do(t, testkit.Request{Method: fiber.MethodPatch, Path: "/user", Token: access, Body: map[string]any{"locale": "en"}}), fiber.StatusOK)
+
+	resp = k.Do(t, testkit.Request{Method: fiber.MethodPost, Path: "/user/export", Token: access})
+	wantStatus(t, resp, fiber.StatusAccepted)
+	link := k.WaitMail(t, testkit.MailExportReady, email).Link
+	_, path, ok := strings.Cut(link, "/api/v1")
+	if !ok {
+		t.Fatalf("unexpected export link %q", link)
+	}
+
+	resp = k.Do(t, testkit.Request{Method: fiber.MethodGet, Path: path})
+	wantStatus(t, resp, fiber.StatusOK)
+	archive := resp.JSON(t)
+	profile, _ := archive["profile"].(map[string]any)
+	if profile["email"] != email || profile["locale"] != "en" {
+		t.Errorf("export profile = %v", profile)
+	}
+	for _, key := range []string{"deleted_at", "purge_after"} {
+		if _, ok := profile[key]; !ok {
+			t.Errorf("export profile has no %q", key)
+		}
+	}
+	sessions, _ := archive["sessions"].([]any)
+	if len(sessions) != 1 {
+		t.Fatalf("export sessions = %v, want one", archive["sessions"])
+	}
+	s := sessions[0].(map[string]any)
+	if s["device_id"] != "device-1" || s["fingerprint"] == nil || s["remember_me"] != true {
+		t.Errorf("export session = %v", s)
+	}
+	for _, key := range []string{"city", "country"} {
+		if _, ok := s[key]; !ok {
+			t.Errorf("export session has no %q", key)
+		}
+	}
+
+	k.Clock.Advance(25 * time.Hour)
+	wantStatus(t, k.Do(t, testkit.Request{Method: fiber.MethodGet, Path: path}), fiber.StatusNotFound)
+}
+
+// TestNotMe — ссылка «это был не я»: GET ничего не меняет, POST завершает
+// сессии и закрывает вход до сброса пароля; повторно ссылка не срабатывает.
+func TestNotMe(t *testing.T) {
+	k := testkit.New(t)
+	wantStatus(t, k.Post(t, "/sign-up", map[string]any{
+		"email": email, "password": password, "first_name": "Anna", "last_name": "Smirnova",
+		"role": "journalist", "privacy_agreement": true,
+	}), fiber.StatusCreated)
+	code := k.WaitMail(t, testkit.MailSignupCode, email).Code
+	wantStatus(t, k.Post(t, "/sign-up/confirm", map[string]any{"email": email, "code": code}), fiber.StatusOK)
+	resp := k.Post(t, "/sign-in", signIn(password, laptop))
+	wantStatus(t, resp, fiber.StatusOK)
+	refresh := resp.String(t, "refresh_token")
+	wantStatus(t, k.Post(t, "/sign-in", signIn(password, phone)), fiber.StatusOK)
+	_, path, ok := strings.Cut(k.WaitMail(t, testkit.MailNewDevice, email).Link, "/api/v1")
+	if !ok {
+		t.Fatal("new device mail has no not-me link")
+	}
+
+	wantStatus(t, k.Do(t, testkit.Request{Method: fiber.MethodGet, Path: path}), fiber.StatusOK)
+	resp = k.Post(t, "/refresh", map[string]any{"refresh_token": refresh})
+	wantStatus(t, resp, fiber.StatusOK)
+	refresh = resp.String(t, "refresh_token")
+
+	wantStatus(t, k.Do(t, testkit.Request{Method: fiber.MethodPost, Path: path}), fiber.StatusOK)
+	wantStatus(t, k.Post(t, "/refresh", map[string]any{"refresh_token": refresh}), fiber.StatusUnauthorized)
+	wantStatus(t, k.Post(t, "/sign-in", signIn(password, laptop)), fiber.StatusForbidden)
+	wantStatus(t, k.Do(t, testkit.Request{Method: fiber.MethodPost, Path: path}), fiber.StatusBadRequest)
+}
+
+// TestResetRequiredSurvivesRehash — вход в заблокированный аккаунт с хешем
+// в устаревшем формате отклоняется и не снимает требование сброса пароля.
+func TestResetRequiredSurvivesRehash(t *testing.T) {
+	k := testkit.New(t)
+	ctx := context.Background()
+	wantStatus(t, k.Post(t, "/sign-up", map[string]any{
+		"email": email, "password": password, "first_name": "Anna", "last_name": "Smirnova",
+		"role": "journalist", "privacy_agreement": true,
+	}), fiber.StatusCreated)
+	code := k.WaitMail(t, testkit.MailSignupCode, email).Code
+	wantStatus(t, k.Post(t, "/sign-up/confirm", map[string]any{"email": email, "code": code}), fiber.StatusOK)
+
+	u, err := k.Repos.Users.GetByEmail(ctx, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Repos.Users.UpdatePasswordHash(ctx, u.ID, string(legacy)); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Repos.Users.RequirePasswordReset(ctx, u.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	wantStatus(t, k.Post(t, "/sign-in", signIn(password, laptop)), fiber.StatusForbidden)
+	u, err = k.Repos.Users.GetByID(ctx, u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !u.PasswordResetRequired || u.PasswordHash == nil || *u.PasswordHash != string(legacy) {
+		t.Fatalf("after sign-in: reset required = %v, hash changed = %v", u.PasswordResetRequired, u.PasswordHash == nil || *u.PasswordHash != string(legacy))
+	}
+}
+
+// failingSignupMail — почта, которая не может отправить код регистрации.
+type failingSignupMail struct{ *testkit.Outbox }
+
+func (failingSignupMail) SendSignupCode(context.Context, string, string) error {
+	return errors.New("smtp: connection refused")
+}
+
+// TestSignUpMailFailure — письмо не ушло: регистрация откатывается и email снова свободен.
+func TestSignUpMailFailure(t *testing.T) {
+	k := testkit.New(t, func(m *authhttp.Module) { m.WithMailer(failingSignupMail{testkit.NewOutbox()}) })
+	body := map[string]any{
+		"email": email, "password": password, "first_name": "Anna", "last_name": "Smirnova",
+		"role": "journalist", "privacy_agreement": true,
+	}
+	wantStatus(t, k.Post(t, "/sign-up", body), fiber.StatusInternalServerError)
+	if ok, err := k.Repos.Users.ExistsByEmail(context.Background(), email); err != nil || ok {
+		t.Fatalf("ExistsByEmail after failed mail = %v, %v; want false", ok, err)
+	}
+}
+
+// TestAuthFlowDeterministic — два прогона сценария дают одинаковые ответы: без
+// этого эталонные файлы не имеют смысла.
+func TestAuthFlowDeterministic(t *testing.T) {
+	run := func() []string {
+		k := testkit.New(t)
+		var out []string
+		resp := k.Post(t, "/sign-up", map[string]any{
+			"email": email, "password": password, "first_name": "Anna", "last_name": "Smirnova",
+			"role": "journalist", "privacy_agreement": true,
+		})
+		out = append(out, string(resp.Body))
+		code := k.Mail.Wait(t, testkit.MailSignupCode, email).Code
+		out = append(out, code)
+		k.Post(t, "/sign-up/confirm", map[string]any{"email": email, "code": code})
+		out = append(out, string(k.Post(t, "/sign-in", signIn(password, laptop)).Body))
+		return out
+	}
+	first, second := run(), run()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Errorf("step %d differs between runs:\n%s\n%s", i, first[i], second[i])
+		}
+	}
+}
+
+func wantStatus(t *testing.T, resp *testkit.Response, want int) {
+	t.Helper()
+	if resp.Status != want {
+		t.Fatalf("status = %d, want %d:\n%s", resp.Status, want, resp.Body)
+	}
+}
+
+func merge(maps ...map[string]any) map[string]any {
+	out := map[string]any{}
+	for _, m := range maps {
+		for k, v := range m {
+			out[k] = v
+		}
+	}
+	return out
+}
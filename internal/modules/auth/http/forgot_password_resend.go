@@ -8,43 +8,46 @@ import (
 	"github.com/gofiber/fiber/v2"
 
 	"auth/internal/modules/auth/domain"
+	"auth/internal/platform/clock"
+	apperrors "auth/internal/platform/errors"
+	"auth/internal/platform/i18n"
 	"auth/internal/platform/security"
 )
 
-func ForgotPasswordResendHandler(userRepo domain.UserRepo, codeRepo domain.CodeRepo) fiber.Handler {
+func ForgotPasswordResendHandler(userRepo domain.UserRepo, codeRepo domain.CodeRepo, clk clock.Clock, rnd *security.Random) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var req forgotReq
 		if err := c.BodyParser(&req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error_code": "INVALID_FIELDS", "message": "Некорректные данные"})
+			return apperrors.ErrInvalidFields
 		}
 		req.Email = strings.ToLower(strings.TrimSpace(req.Email))
 		if _, err := mail.ParseAddress(req.Email); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error_code": "INVALID_EMAIL", "message": "Некорректный формат email"})
+			return errInvalidEmail
 		}
-		u, err := userRepo.GetByEmail(req.Email)
+		u, err := userRepo.GetByEmail(c.UserContext(), req.Email)
 		if err != nil || u == nil {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error_code": "NOT_FOUND", "message": "Пользователь не найден"})
+			return apperrors.ErrNotFound.WithMessageID("user_not_found")
 		}
 
-		ok, _ := codeRepo.ResendAllowed(u.ID, domain.CodeReset)
+		ok, _ := codeRepo.ResendAllowed(c.UserContext(), u.ID, domain.CodeReset)
 		if !ok {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error_code": "RATE_LIMIT_EXCEEDED", "message": "Слишком много запросов. Попробуйте позже"})
+			return errRateLimited
 		}
 
-		code, err := security.RandomDigits(6)
+		code, err := rnd.Digits(6)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error_code": "SERVER_ERROR", "message": "Не удалось отправить код"})
+			return apperrors.ErrInternal.WithMessageID("code_send_failed").Wrap(err)
 		}
-		if err := codeRepo.Save(domain.VerificationCode{
+		if err := codeRepo.Save(c.UserContext(), domain.VerificationCode{
 			UserID:    u.ID,
 			Kind:      domain.CodeReset,
 			Code:      code,
-			ExpiresAt: time.Now().Add(1 * time.Hour),
+			ExpiresAt: clk.Now().Add(1 * time.Hour),
 			SentTo:    u.Email,
 		}); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error_code": "SERVER_ERROR", "message": "Не удалось отправить код"})
+			return apperrors.ErrInternal.WithMessageID("code_send_failed").Wrap(err)
 		}
 
-		return c.JSON(fiber.Map{"message": "Код восстановления отправлен повторно"})
+		return c.JSON(fiber.Map{"message": i18n.Msg(c, "reset_code_resent")})
 	}
 }
@@ -0,0 +1,115 @@
+package http
+
+import "auth/internal/platform/i18n"
+
+// Тексты ответов модуля: коды ошибок и сообщения об успехе.
+func init() {
+	i18n.Register(i18n.Catalog{
+		// коды ошибок
+		"ACCOUNT_BLOCKED":          {i18n.RU: "Аккаунт заблокирован", i18n.EN: "Account is blocked"},
+		"ACCOUNT_PENDING_DELETION": {i18n.RU: "Аккаунт запланирован к удалению. Повторите вход с restore=true, чтобы восстановить его", i18n.EN: "The account is scheduled for deletion. Sign in again with restore=true to restore it"},
+		"ALREADY_CONFIRMED":        {i18n.RU: "Email уже подтверждён", i18n.EN: "Email is already confirmed"},
+		"ALREADY_SCHEDULED":        {i18n.RU: "Аккаунт уже запланирован к удалению", i18n.EN: "The account is already scheduled for deletion"},
+		"EMAIL_NOT_CONFIRMED":      {i18n.RU: "Подтвердите email перед входом", i18n.EN: "Confirm your email before signing in"},
+		"EXPORT_IN_PROGRESS":       {i18n.RU: "Выгрузка уже готовится", i18n.EN: "An export is already being prepared"},
+		"INVALID_CREDENTIALS":      {i18n.RU: "Некорректный email или пароль", i18n.EN: "Invalid email or password"},
+		"INVALID_EMAIL":            {i18n.RU: "Некорректный формат email", i18n.EN: "Invalid email format"},
+		"INVALID_LINK":             {i18n.RU: "Ссылка недействительна или устарела", i18n.EN: "The link is invalid or has expired"},
+		"INVALID_PASSWORD":         {i18n.RU: "Неверный пароль", i18n.EN: "Incorrect password"},
+		"INVALID_PROVIDER":         {i18n.RU: "Не указан провайдер", i18n.EN: "Provider is not specified"},
+		"INVALID_REFRESH":          {i18n.RU: "Невалидный или истёкший refresh_token", i18n.EN: "Invalid or expired refresh_token"},
+		"INVALID_STATE":            {i18n.RU: "Операция недоступна", i18n.EN: "Operation is not available"},
+		"INVALID_TOKEN":            {i18n.RU: "Некорректный OAuth-токен", i18n.EN: "Invalid OAuth token"},
+		"MAIL_SEND_ERROR":          {i18n.RU: "Не удалось отправить письмо с кодом", i18n.EN: "Failed to send the email with the code"},
+		"PASSWORD_RESET_REQUIRED":  {i18n.RU: "Для входа необходимо восстановить пароль", i18n.EN: "You need to reset your password to sign in"},
+		"RATE_LIMIT_EXCEEDED":      {i18n.RU: "Слишком много запросов. Попробуйте позже", i18n.EN: "Too many requests. Try again later"},
+		"REAUTH_REQUIRED":          {i18n.RU: "Подтвердите удаление кодом из письма", i18n.EN: "Confirm the deletion with the code from the email"},
+		"SAME_PASSWORD":            {i18n.RU: "Новый пароль должен отличаться от текущего", i18n.EN: "The new password must differ from the current one"},
+		"SESSION_LIMIT_EXCEEDED":   {i18n.RU: "Достигнут лимит активных сессий. Завершите сессию на другом устройстве", i18n.EN: "Active session limit reached. End a session on another device"},
+		"WEAK_PASSWORD":            {i18n.RU: "Пароль не соответствует требованиям", i18n.EN: "Password does not meet the requirements"},
+
+		// уточнённые сообщения ошибок
+		"user_not_found":                    {i18n.RU: "Пользователь не найден", i18n.EN: "User not found"},
+		"code_send_failed":                  {i18n.RU: "Не удалось отправить код", i18n.EN: "Failed to send the code"},
+		"access_token_failed":               {i18n.RU: "Не удалось создать access_token", i18n.EN: "Failed to create access_token"},
+		"code_generate_failed":              {i18n.RU: "Не удалось сгенерировать код", i18n.EN: "Failed to generate a code"},
+		"password_hash_failed":              {i18n.RU: "Не удалось обработать пароль", i18n.EN: "Failed to process the password"},
+		"account_restore_failed":            {i18n.RU: "Не удалось восстановить аккаунт", i18n.EN: "Failed to restore the account"},
+		"password_required":                 {i18n.RU: "Пароль обязателен", i18n.EN: "Password is required"},
+		"confirmation_code_invalid":         {i18n.RU: "Некорректный код подтверждения", i18n.EN: "Invalid confirmation code"},
+		"reset_code_invalid":                {i18n.RU: "Некорректный код восстановления", i18n.EN: "Invalid recovery code"},
+		"code_invalid_or_expired":           {i18n.RU: "Некорректный или истёкший код", i18n.EN: "Invalid or expired code"},
+		"code_save_failed":                  {i18n.RU: "Не удалось сохранить код", i18n.EN: "Failed to save the code"},
+		"confirmation_code_save_failed":     {i18n.RU: "Не удалось сохранить код подтверждения", i18n.EN: "Failed to save the confirmation code"},
+		"refresh_token_failed":              {i18n.RU: "Не удалось создать refresh_token", i18n.EN: "Failed to create refresh_token"},
+		"user_load_failed":                  {i18n.RU: "Не удалось получить пользователя", i18n.EN: "Failed to load the user"},
+		"link_invalid":                      {i18n.RU: "Ссылка недействительна или устарела", i18n.EN: "The link is invalid or has expired"},
+		"session_not_found":                 {i18n.RU: "Сессия не найдена", i18n.EN: "Session not found"},
+		"twofa_not_pending":                 {i18n.RU: "Пользователь не найден или 2FA не включена", i18n.EN: "User not found or 2FA is not enabled"},
+		"sort_param_invalid":                {i18n.RU: "Параметр sort может быть created_at или last_active", i18n.EN: "The sort parameter must be created_at or last_active"},
+		"active_param_invalid":              {i18n.RU: "Параметр active должен быть true или false", i18n.EN: "The active parameter must be true or false"},
+		"device_id_required":                {i18n.RU: "Нужно указать device_id", i18n.EN: "device_id is required"},
+		"password_incorrect":                {i18n.RU: "Некорректный пароль", i18n.EN: "Incorrect password"},
+		"request_invalid":                   {i18n.RU: "Некорректный запрос", i18n.EN: "Invalid request"},
+		"twofa_disable_unavailable":         {i18n.RU: "Невозможно отключить 2FA", i18n.EN: "2FA cannot be disabled"},
+		"current_password_incorrect":        {i18n.RU: "Неверный текущий пароль", i18n.EN: "Current password is incorrect"},
+		"account_delete_failed":             {i18n.RU: "Не удалось удалить аккаунт", i18n.EN: "Failed to delete the account"},
+		"session_create_failed":             {i18n.RU: "Не удалось создать сессию", i18n.EN: "Failed to create a session"},
+		"confirmation_code_generate_failed": {i18n.RU: "Не удалось сгенерировать код подтверждения", i18n.EN: "Failed to generate a confirmation code"},
+		"password_reset_failed":             {i18n.RU: "Не удалось сбросить пароль", i18n.EN: "Failed to reset the password"},
+		"resend_check_failed":               {i18n.RU: "Не удалось проверить лимит отправки", i18n.EN: "Failed to check the resend limit"},
+		"email_check_failed":                {i18n.RU: "Не удалось проверить email", i18n.EN: "Failed to check the email"},
+		"email_confirm_failed":              {i18n.RU: "Не удалось подтвердить email", i18n.EN: "Failed to confirm the email"},
+		"twofa_disable_failed":              {i18n.RU: "Не удалось отключить 2FA", i18n.EN: "Failed to disable 2FA"},
+		"session_update_failed":             {i18n.RU: "Не удалось обновить сессию", i18n.EN: "Failed to update the session"},
+		"profile_update_failed":             {i18n.RU: "Не удалось обновить профиль", i18n.EN: "Failed to update the profile"},
+		"password_change_failed":            {i18n.RU: "Не удалось изменить пароль", i18n.EN: "Failed to change the password"},
+		"signup_failed":                     {i18n.RU: "Не удалось зарегистрировать пользователя", i18n.EN: "Failed to register the user"},
+		"export_start_failed":               {i18n.RU: "Не удалось запустить выгрузку", i18n.EN: "Failed to start the export"},
+		"data_load_failed":                  {i18n.RU: "Не удалось загрузить данные", i18n.EN: "Failed to load data"},
+		"session_revoke_failed":             {i18n.RU: "Не удалось завершить сессию", i18n.EN: "Failed to end the session"},
+		"sessions_revoke_failed":            {i18n.RU: "Не удалось завершить сессии", i18n.EN: "Failed to end the sessions"},
+		"signin_block_failed":               {i18n.RU: "Не удалось заблокировать вход", i18n.EN: "Failed to block sign-in"},
+		"twofa_enable_failed":               {i18n.RU: "Не удалось включить 2FA", i18n.EN: "Failed to enable 2FA"},
+		"confirmation_code_expired":         {i18n.RU: "Код подтверждения истёк", i18n.EN: "The confirmation code has expired"},
+		"reset_code_expired":                {i18n.RU: "Код восстановления истёк", i18n.EN: "The recovery code has expired"},
+		"delete_password_required":          {i18n.RU: "Для удаления аккаунта укажите пароль", i18n.EN: "Enter your password to delete the account"},
+		"password_not_set":                  {i18n.RU: "Для аккаунта не задан пароль. Воспользуйтесь восстановлением пароля", i18n.EN: "The account has no password. Use password recovery"},
+		"email_and_code_required":           {i18n.RU: "Email и код обязательны", i18n.EN: "Email and code are required"},
+
+		// нарушения политики паролей (details[].rule)
+		"password.min_length":    {i18n.RU: "Пароль слишком короткий", i18n.EN: "Password is too short"},
+		"password.max_length":    {i18n.RU: "Пароль слишком длинный", i18n.EN: "Password is too long"},
+		"password.letter":        {i18n.RU: "Пароль должен содержать хотя бы одну букву", i18n.EN: "Password must contain at least one letter"},
+		"password.upper":         {i18n.RU: "Пароль должен содержать хотя бы одну заглавную букву", i18n.EN: "Password must contain at least one uppercase letter"},
+		"password.digit":         {i18n.RU: "Пароль должен содержать хотя бы одну цифру", i18n.EN: "Password must contain at least one digit"},
+		"password.symbol":        {i18n.RU: "Пароль должен содержать хотя бы один спецсимвол", i18n.EN: "Password must contain at least one special character"},
+		"password.personal_data": {i18n.RU: "Пароль не должен содержать email или имя", i18n.EN: "Password must not contain your email or name"},
+		"password.common":        {i18n.RU: "Пароль слишком распространённый", i18n.EN: "Password is too common"},
+		"password.breached":      {i18n.RU: "Пароль встречается в утечках данных, выберите другой", i18n.EN: "Password appears in data breaches, choose another one"},
+
+		// успешные ответы
+		"signin_oauth_success":       {i18n.RU: "Вход через провайдера успешен", i18n.EN: "Signed in with the provider"},
+		"signin_2fa_success":         {i18n.RU: "Вход завершён", i18n.EN: "Sign-in completed"},
+		"signin_2fa_required":        {i18n.RU: "Требуется подтверждение двухфакторной аутентификации", i18n.EN: "Two-factor authentication is required"},
+		"signin_success":             {i18n.RU: "Вход успешен", i18n.EN: "Signed in successfully"},
+		"twofa_disabled":             {i18n.RU: "2FA отключена", i18n.EN: "2FA disabled"},
+		"email_confirmed":            {i18n.RU: "Email успешно подтверждён", i18n.EN: "Email confirmed"},
+		"twofa_enabled":              {i18n.RU: "2FA включена", i18n.EN: "2FA enabled"},
+		"tokens_refreshed":           {i18n.RU: "Токены обновлены", i18n.EN: "Tokens refreshed"},
+		"password_reset_success":     {i18n.RU: "Пароль успешно сброшен", i18n.EN: "Password has been reset"},
+		"reset_code_sent":            {i18n.RU: "Код восстановления отправлен на email", i18n.EN: "A recovery code has been sent to your email"},
+		"confirmation_code_resent":   {i18n.RU: "Код подтверждения отправлен повторно", i18n.EN: "The confirmation code has been sent again"},
+		"not_me_confirm":             {i18n.RU: "Подтвердите, что вход выполнили не вы: все сессии будут завершены, а для входа понадобится восстановить пароль", i18n.EN: "Confirm that this sign-in was not you: all sessions will be ended and you will need to reset your password to sign in"},
+		"not_me_success":             {i18n.RU: "Все сессии завершены. Восстановите пароль, чтобы снова войти в аккаунт", i18n.EN: "All sessions have been ended. Reset your password to sign in again"},
+		"reset_code_resent":          {i18n.RU: "Код восстановления отправлен повторно", i18n.EN: "The recovery code has been sent again"},
+		"session_revoked":            {i18n.RU: "Сессия успешно завершена", i18n.EN: "Session ended"},
+		"other_sessions_revoked":     {i18n.RU: "Все остальные сессии завершены", i18n.EN: "All other sessions have been ended"},
+		"signup_success":             {i18n.RU: "Регистрация успешна. Подтвердите email", i18n.EN: "Registration successful. Please confirm your email"},
+		"profile_updated":            {i18n.RU: "Профиль успешно обновлён", i18n.EN: "Profile updated"},
+		"password_changed":           {i18n.RU: "Пароль успешно изменён", i18n.EN: "Password changed"},
+		"account_deletion_scheduled": {i18n.RU: "Аккаунт будет удалён. До указанной даты его можно восстановить, выполнив вход", i18n.EN: "The account will be deleted. You can restore it by signing in before the specified date"},
+		"confirmation_code_sent":     {i18n.RU: "Код подтверждения отправлен на email", i18n.EN: "A confirmation code has been sent to your email"},
+		"export_started":             {i18n.RU: "Выгрузка готовится. Ссылка на скачивание придёт на email", i18n.EN: "The export is being prepared. A download link will be sent to your email"},
+	})
+}
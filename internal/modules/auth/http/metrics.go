@@ -0,0 +1,70 @@
+package http
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	apperrors "auth/internal/platform/errors"
+)
+
+// Метрики сценариев входа. outcome: success | failure | 2fa_required,
+// error_code — код ошибки из ответа (пусто при успехе).
+var (
+	signUps = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_signups_total", Help: "Регистрации.",
+	}, []string{"outcome", "error_code"})
+	signUpConfirmations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_signup_confirmations_total", Help: "Подтверждения email после регистрации.",
+	}, []string{"outcome", "error_code"})
+	signIns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_signins_total", Help: "Входы по способу (password, oauth, 2fa).",
+	}, []string{"method", "outcome", "error_code"})
+	twoFAChallenges = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "auth_2fa_challenges_total", Help: "Запросы второго фактора при входе.",
+	})
+	refreshes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_refreshes_total", Help: "Обновления токенов.",
+	}, []string{"outcome", "error_code"})
+)
+
+type flowResponse struct {
+	ErrorCode   string `json:"error_code"`
+	Requires2FA bool   `json:"requires_2fa"`
+}
+
+// trackFlow считает исход запроса по статусу и JSON-ответу обработчика,
+// чтобы не расставлять счётчики в каждой ветке ошибки.
+func trackFlow(vec *prometheus.CounterVec, labels ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// ошибку превращаем в ответ здесь, чтобы прочитать из него error_code
+		err := c.Next()
+		if err != nil {
+			err = c.App().ErrorHandler(c, err)
+		}
+
+		var resp flowResponse
+		if isJSON(string(c.Response().Header.ContentType())) {
+			_ = json.Unmarshal(c.Response().Body(), &resp)
+		}
+		outcome := "success"
+		switch {
+		case err != nil || c.Response().StatusCode() >= fiber.StatusBadRequest:
+			outcome = "failure"
+		case resp.Requires2FA:
+			outcome = "2fa_required"
+			twoFAChallenges.Inc()
+		}
+		values := append(append([]string{}, labels...), outcome, resp.ErrorCode)
+		vec.WithLabelValues(values...).Inc()
+		return err
+	}
+}
+
+// isJSON — application/json или application/problem+json.
+func isJSON(contentType string) bool {
+	return strings.HasPrefix(contentType, fiber.MIMEApplicationJSON) || strings.HasPrefix(contentType, apperrors.MIMEProblemJSON)
+}
@@ -0,0 +1,215 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"auth/internal/modules/auth/domain"
+	"auth/internal/platform/clock"
+	apperrors "auth/internal/platform/errors"
+	"auth/internal/platform/geo"
+	"auth/internal/platform/i18n"
+	"auth/internal/platform/logging"
+	"auth/internal/platform/notify"
+	"auth/internal/platform/security"
+	"auth/internal/platform/useragent"
+)
+
+// сколько живёт ссылка «это был не я» из письма
+const notMeLinkTTL = 7 * 24 * time.Hour
+
+// deviceInfo — данные об устройстве из запроса на вход.
+type deviceInfo struct {
+	name        string
+	id          string
+	ip          string
+	ua          string
+	fingerprint string
+	location    geo.Location
+	remember    bool
+	expiresAt   time.Time
+}
+
+func (d deviceInfo) newSession(userID, refreshHash string) domain.Session {
+	s := domain.Session{
+		UserID:           userID,
+		RefreshTokenHash: refreshHash,
+		DeviceName:       &d.name,
+		IPAddress:        &d.ip,
+		UserAgent:        &d.ua,
+		Fingerprint:      &d.fingerprint,
+		ExpiresAt:        d.expiresAt,
+		RememberMe:       d.remember,
+	}
+	if d.id != "" {
+		s.DeviceID = &d.id
+	}
+	if d.location.City != "" {
+		s.City = &d.location.City
+	}
+	if d.location.Country != "" {
+		s.Country = &d.location.Country
+	}
+	return s
+}
+
+// deviceTracker собирает данные об устройстве при входе и предупреждает
+// пользователя о входе с нового устройства.
+type deviceTracker struct {
+	mailer  notify.Sender
+	signer  *security.Signer
+	codes   domain.CodeRepo // одноразовые nonce ссылок «это был не я»
+	random  *security.Random
+	baseURL string
+	geo     geo.Locator
+	policy  domain.SessionPolicy
+	clock   clock.Clock
+}
+
+// fromRequest собирает данные устройства; remember — флаг «запомнить меня» со входа.
+func (t *deviceTracker) fromRequest(c *fiber.Ctx, name, deviceID string, remember bool) deviceInfo {
+	now := t.clock.Now()
+	ua := c.Get("User-Agent")
+	deviceID = strings.TrimSpace(deviceID)
+	d := deviceInfo{
+		name:        name,
+		id:          deviceID,
+		ip:          c.IP(),
+		ua:          ua,
+		fingerprint: useragent.Fingerprint(ua, deviceID),
+		remember:    remember,
+		expiresAt:   t.policy.ExpiresAt(now, now, remember),
+	}
+	d.location, _ = t.geo.Lookup(d.ip)
+	return d
+}
+
+// createSession создаёт сессию с учётом лимита активных сессий для роли пользователя.
+// Ошибка — domain.ErrSessionLimit или SERVER_ERROR, её можно вернуть из обработчика как есть.
+func (t *deviceTracker) createSession(ctx context.Context, sessions domain.SessionRepo, u *domain.User, d deviceInfo, refreshHash string) (*domain.Session, error) {
+	s, err := sessions.CreateWithLimit(ctx, d.newSession(u.ID, refreshHash), t.policy.Limit(u.Role, t.clock.Now()))
+	if err != nil && !errors.Is(err, domain.ErrSessionLimit) {
+		return nil, apperrors.ErrInternal.WithMessageID("session_create_failed").Wrap(err)
+	}
+	return s, err
+}
+
+// knownDevice проверяет устройство до создания сессии. Ошибку проверки считаем
+// «устройство знакомо»: лишнее письмо хуже, чем пропущенный вход.
+func (t *deviceTracker) knownDevice(ctx context.Context, sessions domain.SessionRepo, userID string, d deviceInfo) bool {
+	known, err := sessions.KnownDevice(ctx, userID, d.fingerprint)
+	if err != nil {
+		logging.FromContext(ctx).Error("check known device", "user_id", userID, "err", err)
+		return true
+	}
+	return known
+}
+
+// alert отправляет письмо со ссылкой «это был не я» (асинхронно; ctx — контекст
+// запроса, от него берётся только трейс). Ссылка одноразовая: её nonce хранится
+// как код CodeNotMe и гасится при подтверждении.
+func (t *deviceTracker) alert(ctx context.Context, u *domain.User, sess *domain.Session, d deviceInfo) {
+	if t.mailer == nil {
+		return
+	}
+	now := t.clock.Now()
+	exp := now.Add(notMeLinkTTL)
+	nonce, err := t.random.Digits(12)
+	if err == nil {
+		err = t.codes.Save(ctx, domain.VerificationCode{
+			UserID: u.ID, Kind: domain.CodeNotMe, Code: nonce, ExpiresAt: exp, SentTo: u.Email,
+		})
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("issue not-me link", "user_id", u.ID, "err", err)
+		return
+	}
+	link := fmt.Sprintf("%s/api/v1/sign-in/not-me?uid=%s&sid=%s&nonce=%s&expires=%d&sig=%s",
+		t.baseURL, url.QueryEscape(u.ID), url.QueryEscape(sess.ID), nonce, exp.Unix(),
+		url.QueryEscape(t.signer.Sign(exp, "not-me", u.ID, sess.ID, nonce)))
+
+	info := useragent.Parse(d.ua)
+	device := fmt.Sprintf("%s, %s", info.Browser, info.OS)
+	if d.name != "" {
+		device = d.name + " (" + device + ")"
+	}
+	where := d.ip
+	if loc := d.location.String(); loc != "" {
+		where += ", " + loc
+	}
+	ctx = context.WithoutCancel(ctx)
+	go func(to string) {
+		if err := t.mailer.SendNewDeviceAlert(ctx, to, device, where, now, link); err != nil {
+			logging.FromContext(ctx).Error("send new device alert", "user_id", u.ID, "err", err)
+		}
+	}(u.Email)
+}
+
+var errInvalidLink = apperrors.New(fiber.StatusBadRequest, "INVALID_LINK")
+
+// notMeLink — параметры ссылки «это был не я»; nil — ссылка повреждена или истекла.
+type notMeLink struct{ uid, sid, nonce string }
+
+func parseNotMeLink(c *fiber.Ctx, signer *security.Signer) *notMeLink {
+	l := notMeLink{uid: c.Query("uid"), sid: c.Query("sid"), nonce: c.Query("nonce")}
+	if l.uid == "" || l.sid == "" || l.nonce == "" ||
+		!signer.Verify(c.Query("sig"), c.Query("expires"), "not-me", l.uid, l.sid, l.nonce) {
+		return nil
+	}
+	return &l
+}
+
+// NotMeConfirmHandler — переход по ссылке «это был не я» из письма о новом входе.
+// Ничего не меняет (ссылку могут открыть сканеры писем и предзагрузка), только
+// просит подтвердить действие POST-запросом на тот же адрес.
+func NotMeConfirmHandler(signer *security.Signer) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if parseNotMeLink(c, signer) == nil {
+			return errInvalidLink
+		}
+		return c.JSON(fiber.Map{
+			"message": i18n.Msg(c, "not_me_confirm"),
+		})
+	}
+}
+
+// NotMeHandler подтверждает «это был не я»: завершает сессии пользователя и
+// требует сменить пароль через восстановление. Повторно ссылка не срабатывает.
+func NotMeHandler(uow domain.UnitOfWork, signer *security.Signer) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		l := parseNotMeLink(c, signer)
+		if l == nil {
+			return errInvalidLink
+		}
+
+		err := uow.Do(c.UserContext(), func(r domain.Repos) error {
+			if _, err := r.Codes.Consume(c.UserContext(), l.uid, domain.CodeNotMe, l.nonce); err != nil {
+				if errors.Is(err, apperrors.ErrCodeInvalid) || errors.Is(err, apperrors.ErrCodeExpired) {
+					return errInvalidLink
+				}
+				return apperrors.ErrInternal.Wrap(err)
+			}
+			// пароль, скорее всего, скомпрометирован — завершаем все сессии, не только подозрительную
+			if _, err := r.Sessions.RevokeAll(c.UserContext(), l.uid); err != nil {
+				return apperrors.ErrInternal.WithMessageID("sessions_revoke_failed").Wrap(err)
+			}
+			if err := r.Users.RequirePasswordReset(c.UserContext(), l.uid); err != nil {
+				return apperrors.ErrInternal.WithMessageID("signin_block_failed").Wrap(err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(fiber.Map{
+			"message": i18n.Msg(c, "not_me_success"),
+		})
+	}
+}
@@ -1,91 +1,122 @@
 package http
 
 import (
+	"errors"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 
 	"auth/internal/modules/auth/domain"
+	apperrors "auth/internal/platform/errors"
+	"auth/internal/platform/i18n"
 	"auth/internal/platform/security"
 )
 
 type oauthReq struct {
 	AccessToken string `json:"access_token"`
 	DeviceName  string `json:"device_name"`
+	DeviceID    string `json:"device_id"`
+	Restore     bool   `json:"restore"`
+	RememberMe  bool   `json:"remember_me"`
 }
 
-func OAuthSignInHandler(userRepo domain.UserRepo, sessions domain.SessionRepo, jwtMgr *security.JWTManager) fiber.Handler {
+func OAuthSignInHandler(userRepo domain.UserRepo, sessions domain.SessionRepo, uow domain.UnitOfWork, jwtMgr *security.JWTManager, devices *deviceTracker, rnd *security.Random) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		provider := strings.ToLower(c.Params("provider"))
 		if provider == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_PROVIDER",
-				"message":    "Не указан провайдер",
-			})
+			return apperrors.New(fiber.StatusBadRequest, "INVALID_PROVIDER")
 		}
 
 		var req oauthReq
 		if err := c.BodyParser(&req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_FIELDS",
-				"message":    "Некорректные данные",
-			})
+			return apperrors.ErrInvalidFields
 		}
 
 		email, err := security.VerifyOAuthToken(provider, req.AccessToken)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_TOKEN",
-				"message":    "Некорректный OAuth-токен",
-			})
+			return apperrors.New(fiber.StatusBadRequest, "INVALID_TOKEN")
 		}
 
 		// ищем пользователя
-		u, _ := userRepo.GetByEmail(email)
-		if u == nil {
-			// создаем нового
-			u, _ = userRepo.Create(domain.CreateUserParams{
-				Email:        email,
-				FirstName:    provider,
-				LastName:     "user",
-				Role:         domain.RoleJournalist,
-				PasswordHash: nil, // пароль не задаём
+		u, err := userRepo.GetByEmail(c.UserContext(), email)
+		switch {
+		case errors.Is(err, apperrors.ErrNotFound):
+			// создаем нового; email подтверждён провайдером. Создание, подтверждение
+			// и привязка провайдера — одной транзакцией, без полусозданных аккаунтов
+			err = uow.Do(c.UserContext(), func(r domain.Repos) error {
+				var err error
+				u, err = r.Users.Create(c.UserContext(), domain.CreateUserParams{
+					Email:        email,
+					FirstName:    provider,
+					LastName:     "user",
+					Role:         domain.RoleJournalist,
+					PasswordHash: nil, // пароль не задаём
+				})
+				if err != nil {
+					return err
+				}
+				if err := r.Users.ConfirmEmail(c.UserContext(), u.ID); err != nil {
+					return err
+				}
+				return r.Users.LinkProvider(c.UserContext(), u.ID, provider)
 			})
+			if err != nil {
+				return apperrors.ErrInternal.WithMessageID("signup_failed").Wrap(err)
+			}
 			u.EmailConfirmed = true
 			u.Providers = append(u.Providers, provider)
-		} else {
-			// добавляем провайдера, если ещё нет
-			found := false
-			for _, p := range u.Providers {
-				if p == provider {
-					found = true
-					break
-				}
+		case err != nil:
+			return apperrors.ErrInternal.Wrap(err)
+		case !slices.Contains(u.Providers, provider):
+			// добавляем провайдера существующему пользователю
+			if err := userRepo.LinkProvider(c.UserContext(), u.ID, provider); err != nil {
+				return apperrors.ErrInternal.Wrap(err)
+			}
+			u.Providers = append(u.Providers, provider)
+		}
+
+		// «это был не я» закрывает и вход через провайдера: сначала сброс пароля
+		if u.PasswordResetRequired {
+			return errPasswordResetRequired
+		}
+
+		if u.DeletedAt != nil {
+			if !req.Restore {
+				return pendingDeletionErr(u)
 			}
-			if !found {
-				u.Providers = append(u.Providers, provider)
+			if err := userRepo.Restore(c.UserContext(), u.ID); err != nil {
+				return apperrors.ErrInternal.WithMessageID("account_restore_failed").Wrap(err)
 			}
 		}
 
 		// создаем сессию
-		rt, _, _ := security.IssueRefresh()
+		rt, err := rnd.RefreshToken()
+		if err != nil {
+			return apperrors.ErrInternal.WithMessageID("refresh_token_failed").Wrap(err)
+		}
 		rth := security.HashToken(rt)
-		ip := c.IP()
-		ua := c.Get("User-Agent")
-		dev := req.DeviceName
-		sess, _ := sessions.Create(domain.Session{
-			UserID:           u.ID,
-			RefreshTokenHash: rth,
-			DeviceName:       &dev,
-			IPAddress:        &ip,
-			UserAgent:        &ua,
-		})
+		dev := devices.fromRequest(c, req.DeviceName, req.DeviceID, req.RememberMe)
+		known := devices.knownDevice(c.UserContext(), sessions, u.ID, dev)
+		sess, err := devices.createSession(c.UserContext(), sessions, u, dev, rth)
+		if err != nil {
+			return err
+		}
+		if !known {
+			devices.alert(c.UserContext(), u, sess, dev)
+		}
+
+		// пользователь подтверждён — дальше отвечаем на его языке
+		i18n.SetLang(c, u.Locale)
 
-		at, exp, _ := jwtMgr.IssueAccess(u.ID, string(u.Role), sess.ID)
+		at, exp, err := jwtMgr.IssueAccess(u.ID, string(u.Role), sess.ID, u.Locale)
+		if err != nil {
+			return apperrors.ErrInternal.WithMessageID("access_token_failed").Wrap(err)
+		}
 
 		return c.JSON(fiber.Map{
-			"message":       "Вход через провайдера успешен",
+			"message":       i18n.Msg(c, "signin_oauth_success"),
 			"access_token":  at,
 			"refresh_token": rt,
 			"expires_at":    exp.UTC().Format(time.RFC3339),
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+
+	"auth/internal/modules/auth/domain"
+	apperrors "auth/internal/platform/errors"
+	"auth/internal/platform/i18n"
+	"auth/internal/platform/logging"
+	"auth/internal/platform/notify"
+	"auth/internal/platform/security"
+)
+
+type changePasswordReq struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"` // остальные правила — в security.PasswordPolicy
+}
+
+// ChangePasswordHandler меняет пароль авторизованного пользователя.
+// Текущая сессия остаётся активной, все остальные завершаются.
+func ChangePasswordHandler(
+	userRepo domain.UserRepo,
+	sessions domain.SessionRepo,
+	mailer notify.Sender,
+	pwPolicy security.PasswordPolicy,
+) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		uid, _ := c.Locals("user_id").(string)
+		sid, _ := c.Locals("session_id").(string)
+		if uid == "" || sid == "" {
+			return apperrors.ErrUnauthorized
+		}
+
+		var req changePasswordReq
+		if err := c.BodyParser(&req); err != nil {
+			return apperrors.ErrInvalidFields
+		}
+		if err := validate.Struct(req); err != nil {
+			return validationErr(err)
+		}
+		if req.NewPassword == req.CurrentPassword {
+			return apperrors.New(fiber.StatusBadRequest, "SAME_PASSWORD")
+		}
+
+		u, err := userRepo.GetByID(c.UserContext(), uid)
+		if err != nil || u == nil {
+			return apperrors.ErrNotFound.WithMessageID("user_not_found")
+		}
+		// аккаунты, созданные через OAuth, пароля не имеют — для них есть reset-password
+		if u.PasswordHash == nil {
+			return errInvalidState.WithMessageID("password_not_set")
+		}
+
+		ok, _, _ := security.CheckPassword(*u.PasswordHash, req.CurrentPassword)
+		if !ok {
+			return errInvalidPassword.WithMessageID("current_password_incorrect")
+		}
+
+		if v := pwPolicy.Validate(req.NewPassword, u.Email, u.FirstName, u.LastName); len(v) > 0 {
+			return weakPasswordErr(c, v)
+		}
+
+		hash, err := security.HashPassword(req.NewPassword)
+		if err != nil {
+			return apperrors.ErrInternal.WithMessageID("password_hash_failed").Wrap(err)
+		}
+		if err := userRepo.UpdatePassword(c.UserContext(), u.ID, hash); err != nil {
+			return apperrors.ErrInternal.WithMessageID("password_change_failed").Wrap(err)
+		}
+
+		// текущую сессию оставляем, остальные завершаем
+		count, err := sessions.RevokeOthers(c.UserContext(), sid, u.ID)
+		if err != nil {
+			logging.FromFiber(c).Error("revoke other sessions", "err", err)
+		}
+
+		// уведомление отправляем асинхронно, чтобы не задерживать ответ
+		if mailer != nil {
+			ctx := context.WithoutCancel(c.UserContext())
+			go func(to string) {
+				if err := mailer.SendPasswordChanged(ctx, to); err != nil {
+					logging.FromContext(ctx).Error("send password change notice", "err", err)
+				}
+			}(u.Email)
+		}
+
+		return c.JSON(fiber.Map{
+			"message":             i18n.Msg(c, "password_changed"),
+			"sessions_terminated": count,
+		})
+	}
+}
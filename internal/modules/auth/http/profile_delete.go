@@ -1,56 +1,132 @@
 package http
 
 import (
+	"context"
+	"strings"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 
 	"auth/internal/modules/auth/domain"
+	"auth/internal/platform/clock"
+	apperrors "auth/internal/platform/errors"
+	"auth/internal/platform/i18n"
+	"auth/internal/platform/logging"
+	"auth/internal/platform/notify"
 	"auth/internal/platform/security"
 )
 
 type deleteReq struct {
 	Password string `json:"password"`
+	Code     string `json:"code"` // для аккаунтов без пароля — код из POST /user/delete/code
 }
 
-func DeleteUserHandler(userRepo domain.UserRepo) fiber.Handler {
+// DeleteUserHandler ставит аккаунт в очередь на удаление. В течение grace-периода
+// вход в аккаунт позволяет его восстановить, после — данные удаляются фоновой задачей.
+func DeleteUserHandler(
+	userRepo domain.UserRepo,
+	codeRepo domain.CodeRepo,
+	sessions domain.SessionRepo,
+	grace time.Duration,
+	clk clock.Clock,
+) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		uid, _ := c.Locals("user_id").(string)
 		if uid == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error_code": "UNAUTHORIZED",
-				"message":    "Требуется авторизация",
-			})
+			return apperrors.ErrUnauthorized
 		}
 
 		var req deleteReq
-		if err := c.BodyParser(&req); err != nil || req.Password == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_FIELDS",
-				"message":    "Пароль обязателен",
-			})
+		if err := c.BodyParser(&req); err != nil {
+			return apperrors.ErrInvalidFields
 		}
 
-		u, err := userRepo.GetByID(uid)
+		u, err := userRepo.GetByID(c.UserContext(), uid)
 		if err != nil || u == nil {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error_code": "NOT_FOUND",
-				"message":    "Пользователь не найден",
-			})
+			return apperrors.ErrNotFound.WithMessageID("user_not_found")
+		}
+		if u.DeletedAt != nil {
+			return apperrors.New(fiber.StatusConflict, "ALREADY_SCHEDULED")
+		}
+
+		if u.PasswordHash != nil {
+			if req.Password == "" {
+				return apperrors.ErrInvalidFields.WithMessageID("password_required")
+			}
+			ok, _, _ := security.CheckPassword(*u.PasswordHash, req.Password)
+			if !ok {
+				return errInvalidPassword.WithMessageID("password_incorrect")
+			}
+		} else {
+			// аккаунт без пароля (OAuth) — подтверждаем кодом из письма
+			code := strings.TrimSpace(req.Code)
+			if len(code) != 6 {
+				return apperrors.New(fiber.StatusBadRequest, "REAUTH_REQUIRED")
+			}
+			if _, err := codeRepo.Consume(c.UserContext(), u.ID, domain.CodeReauth, code); err != nil {
+				return apperrors.ErrCodeInvalid.WithMessageID("code_invalid_or_expired")
+			}
+		}
+
+		purgeAfter := clk.Now().Add(grace).UTC()
+		if err := userRepo.ScheduleDeletion(c.UserContext(), uid, purgeAfter); err != nil {
+			return apperrors.ErrInternal.WithMessageID("account_delete_failed").Wrap(err)
 		}
+		if _, err := sessions.RevokeAll(c.UserContext(), uid); err != nil {
+			logging.FromFiber(c).Error("revoke sessions of deleted user", "err", err)
+		}
+
+		return c.JSON(fiber.Map{
+			"message":     i18n.Msg(c, "account_deletion_scheduled"),
+			"purge_after": purgeAfter.Format(time.RFC3339),
+		})
+	}
+}
 
-		ok, _ := security.CheckPassword(*u.PasswordHash, req.Password)
+// RequestDeletionCodeHandler отправляет код подтверждения удаления для аккаунтов без пароля.
+func RequestDeletionCodeHandler(userRepo domain.UserRepo, codeRepo domain.CodeRepo, mailer notify.Sender, clk clock.Clock, rnd *security.Random) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		uid, _ := c.Locals("user_id").(string)
+		if uid == "" {
+			return apperrors.ErrUnauthorized
+		}
+
+		u, err := userRepo.GetByID(c.UserContext(), uid)
+		if err != nil || u == nil {
+			return apperrors.ErrNotFound.WithMessageID("user_not_found")
+		}
+		if u.PasswordHash != nil {
+			return errInvalidState.WithMessageID("delete_password_required")
+		}
+
+		ok, _ := codeRepo.ResendAllowed(c.UserContext(), u.ID, domain.CodeReauth)
 		if !ok {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_PASSWORD",
-				"message":    "Некорректный пароль",
-			})
+			return errRateLimited
 		}
 
-		if err := userRepo.Delete(uid); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR",
-				"message":    "Не удалось удалить аккаунт",
-			})
+		code, err := rnd.Digits(6)
+		if err != nil {
+			return apperrors.ErrInternal.WithMessageID("code_generate_failed").Wrap(err)
+		}
+		if err := codeRepo.Save(c.UserContext(), domain.VerificationCode{
+			UserID:    u.ID,
+			Kind:      domain.CodeReauth,
+			Code:      code,
+			ExpiresAt: clk.Now().Add(10 * time.Minute),
+			SentTo:    u.Email,
+		}); err != nil {
+			return apperrors.ErrInternal.WithMessageID("code_save_failed").Wrap(err)
 		}
-		return c.JSON(fiber.Map{"message": "Аккаунт успешно удалён"})
+
+		if mailer != nil {
+			ctx := context.WithoutCancel(c.UserContext())
+			go func(to string) {
+				if err := mailer.SendReauthCode(ctx, to, code); err != nil {
+					logging.FromContext(ctx).Error("send reauth code", "err", err)
+				}
+			}(u.Email)
+		}
+
+		return c.JSON(fiber.Map{"message": i18n.Msg(c, "confirmation_code_sent")})
 	}
 }
@@ -6,24 +6,19 @@ import (
 	"github.com/gofiber/fiber/v2"
 
 	"auth/internal/modules/auth/domain"
+	apperrors "auth/internal/platform/errors"
 )
 
 func GetProfileHandler(userRepo domain.UserRepo) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		uid, _ := c.Locals("user_id").(string)
 		if uid == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error_code": "UNAUTHORIZED",
-				"message":    "Требуется авторизация",
-			})
+			return apperrors.ErrUnauthorized
 		}
 
-		u, err := userRepo.GetByID(uid)
+		u, err := userRepo.GetByID(c.UserContext(), uid)
 		if err != nil || u == nil {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error_code": "NOT_FOUND",
-				"message":    "Пользователь не найден",
-			})
+			return apperrors.ErrNotFound.WithMessageID("user_not_found")
 		}
 
 		return c.JSON(fiber.Map{
@@ -33,6 +28,7 @@ func GetProfileHandler(userRepo domain.UserRepo) fiber.Handler {
 			"last_name":  u.LastName,
 			"role":       u.Role,
 			"phone":      u.Phone,
+			"locale":     u.Locale,
 			"created_at": u.CreatedAt.UTC().Format(time.RFC3339),
 		})
 	}
@@ -1,47 +1,59 @@
 package http
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 
 	"auth/internal/modules/auth/domain"
+	apperrors "auth/internal/platform/errors"
+	"auth/internal/platform/i18n"
 )
 
 type updateProfileReq struct {
 	FirstName *string `json:"first_name"`
 	LastName  *string `json:"last_name"`
 	Phone     *string `json:"phone"`
+	Locale    *string `json:"locale"` // ru | en; пустая строка — сбросить
 }
 
 func UpdateProfileHandler(userRepo domain.UserRepo) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		uid, _ := c.Locals("user_id").(string)
 		if uid == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error_code": "UNAUTHORIZED",
-				"message":    "Требуется авторизация",
-			})
+			return apperrors.ErrUnauthorized
 		}
 
 		var req updateProfileReq
 		if err := c.BodyParser(&req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_FIELDS",
-				"message":    "Некорректные данные",
-			})
+			return apperrors.ErrInvalidFields
+		}
+
+		var locale string
+		if req.Locale != nil && *req.Locale != "" {
+			l, ok := i18n.Supported(*req.Locale)
+			if !ok {
+				return apperrors.ErrValidation.WithFields(apperrors.FieldError{Field: "locale", Rule: "oneof"})
+			}
+			locale = l
 		}
 
-		if err := userRepo.UpdateProfile(uid, req.FirstName, req.LastName, req.Phone); err != nil {
-			if err.Error() == "not_found" {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error_code": "NOT_FOUND",
-					"message":    "Пользователь не найден",
-				})
+		if err := userRepo.UpdateProfile(c.UserContext(), uid, req.FirstName, req.LastName, req.Phone); err != nil {
+			if errors.Is(err, apperrors.ErrNotFound) {
+				return apperrors.ErrNotFound.WithMessageID("user_not_found")
+			}
+			return apperrors.ErrInternal.WithMessageID("profile_update_failed").Wrap(err)
+		}
+		if req.Locale != nil {
+			if err := userRepo.SetLocale(c.UserContext(), uid, locale); err != nil {
+				return apperrors.ErrInternal.WithMessageID("profile_update_failed").Wrap(err)
+			}
+			// отвечаем уже на выбранном языке; в остальных запросах язык берётся
+			// из access-токена и сменится после /refresh (см. plathttp.JWTAuth)
+			if locale != "" {
+				i18n.SetLang(c, locale)
 			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR",
-				"message":    "Не удалось обновить профиль",
-			})
 		}
-		return c.JSON(fiber.Map{"message": "Профиль успешно обновлён"})
+		return c.JSON(fiber.Map{"message": i18n.Msg(c, "profile_updated")})
 	}
 }
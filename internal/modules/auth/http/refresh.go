@@ -4,6 +4,9 @@ import (
 	"time"
 
 	"auth/internal/modules/auth/domain"
+	"auth/internal/platform/clock"
+	apperrors "auth/internal/platform/errors"
+	"auth/internal/platform/i18n"
 	"auth/internal/platform/security"
 
 	"github.com/gofiber/fiber/v2"
@@ -11,78 +14,59 @@ import (
 
 type refreshReq struct {
 	RefreshToken string `json:"refresh_token"`
-	DeviceName   string `json:"device_name"`
 }
 
 func RefreshHandler(
 	sessions domain.SessionRepo,
 	userRepo domain.UserRepo, // <— добавили
 	jwtMgr *security.JWTManager,
+	policy domain.SessionPolicy,
+	clk clock.Clock,
+	rnd *security.Random,
 ) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var req refreshReq
 		if err := c.BodyParser(&req); err != nil || req.RefreshToken == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_FIELDS",
-				"message":    "Некорректный запрос",
-			})
+			return apperrors.ErrInvalidFields.WithMessageID("request_invalid")
 		}
 
 		hash := security.HashToken(req.RefreshToken)
-		s, err := sessions.FindByRefreshHash(hash)
-		if err != nil || s == nil || s.RevokedAt != nil || time.Now().After(s.ExpiresAt) {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error_code": "INVALID_REFRESH",
-				"message":    "Невалидный или истёкший refresh_token",
-			})
+		s, err := sessions.FindByRefreshHash(c.UserContext(), hash)
+		now := clk.Now()
+		if err != nil || s == nil || !policy.Active(*s, now) {
+			return errInvalidRefresh
 		}
 
-		// инвалидируем старый
-		_ = sessions.Revoke(s.ID, s.UserID)
-
-		// создаём новый refresh → новая сессия
-		rt, _, err := security.IssueRefresh()
+		// меняем refresh-токен в той же сессии: sid в access-токенах не меняется,
+		// а last_active и IP показывают последнее обновление
+		rt, err := rnd.RefreshToken()
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR", "message": "Не удалось создать refresh",
-			})
+			return apperrors.ErrInternal.WithMessageID("refresh_token_failed").Wrap(err)
 		}
-		rth := security.HashToken(rt)
-		ip := c.IP()
-		ua := c.Get("User-Agent")
-		dev := req.DeviceName
-
-		newSess, err := sessions.Create(domain.Session{
-			UserID:           s.UserID,
-			RefreshTokenHash: rth,
-			DeviceName:       &dev,
-			IPAddress:        &ip,
-			UserAgent:        &ua,
-			ExpiresAt:        time.Now().Add(30 * 24 * time.Hour),
-		})
-		if err != nil || newSess == nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR", "message": "Не удалось создать сессию",
-			})
+		ok, err := sessions.Rotate(c.UserContext(), s.ID, hash, security.HashToken(rt), c.IP(), policy.ExpiresAt(s.CreatedAt, now, s.RememberMe))
+		if err != nil {
+			return apperrors.ErrInternal.WithMessageID("session_update_failed").Wrap(err)
+		}
+		if !ok { // токен уже использован параллельным запросом
+			return errInvalidRefresh
 		}
 
 		// достаём роль пользователя
-		u, err := userRepo.GetByID(s.UserID)
+		u, err := userRepo.GetByID(c.UserContext(), s.UserID)
 		if err != nil || u == nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR", "message": "Не удалось получить пользователя",
-			})
+			return apperrors.ErrInternal.WithMessageID("user_load_failed").Wrap(err)
 		}
 
-		at, exp, err := jwtMgr.IssueAccess(s.UserID, string(u.Role), newSess.ID)
+		// пользователь подтверждён — дальше отвечаем на его языке
+		i18n.SetLang(c, u.Locale)
+
+		at, exp, err := jwtMgr.IssueAccess(s.UserID, string(u.Role), s.ID, u.Locale)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR", "message": "Не удалось создать access_token",
-			})
+			return apperrors.ErrInternal.WithMessageID("access_token_failed").Wrap(err)
 		}
 
 		return c.JSON(fiber.Map{
-			"message":       "Токены обновлены",
+			"message":       i18n.Msg(c, "tokens_refreshed"),
 			"access_token":  at,
 			"refresh_token": rt,
 			"expires_at":    exp.UTC().Format(time.RFC3339),
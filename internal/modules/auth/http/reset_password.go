@@ -1,12 +1,15 @@
 package http
 
 import (
+	"errors"
 	"net/mail"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
 
 	"auth/internal/modules/auth/domain"
+	apperrors "auth/internal/platform/errors"
+	"auth/internal/platform/i18n"
 	"auth/internal/platform/security"
 )
 
@@ -16,49 +19,63 @@ type resetReq struct {
 	NewPassword string `json:"new_password"`
 }
 
-func ResetPasswordHandler(userRepo domain.UserRepo, codeRepo domain.CodeRepo, sessions domain.SessionRepo) fiber.Handler {
+// ResetPasswordHandler гасит код, меняет пароль и завершает все сессии в одной
+// транзакции: код не сгорает, если пароль сменить не удалось.
+func ResetPasswordHandler(userRepo domain.UserRepo, uow domain.UnitOfWork, pwPolicy security.PasswordPolicy) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var req resetReq
 		if err := c.BodyParser(&req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error_code": "INVALID_FIELDS", "message": "Некорректные данные"})
+			return apperrors.ErrInvalidFields
 		}
 		req.Email = strings.ToLower(strings.TrimSpace(req.Email))
 		req.Code = strings.TrimSpace(req.Code)
 
 		if _, err := mail.ParseAddress(req.Email); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error_code": "INVALID_EMAIL", "message": "Некорректный формат email"})
+			return errInvalidEmail
 		}
 		if len(req.Code) != 6 {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error_code": "INVALID_CODE", "message": "Некорректный код восстановления"})
-		}
-		if len(req.NewPassword) < 8 || len(req.NewPassword) > 50 {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error_code": "INVALID_PASSWORD", "message": "Пароль должен быть от 8 до 50 символов"})
+			return apperrors.ErrCodeInvalid.WithMessageID("reset_code_invalid")
 		}
 
-		u, err := userRepo.GetByEmail(req.Email)
+		u, err := userRepo.GetByEmail(c.UserContext(), req.Email)
 		if err != nil || u == nil {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error_code": "NOT_FOUND", "message": "Пользователь не найден"})
+			return apperrors.ErrNotFound.WithMessageID("user_not_found")
 		}
 
-		if _, err := codeRepo.Consume(u.ID, domain.CodeReset, req.Code); err != nil {
-			// у нас есть разделение на INVALID/EXPIRED в памяти — отразим:
-			if err.Error() == "code_expired" {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error_code": "CODE_EXPIRED", "message": "Код восстановления истёк"})
-			}
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error_code": "INVALID_CODE", "message": "Некорректный код восстановления"})
+		if v := pwPolicy.Validate(req.NewPassword, u.Email, u.FirstName, u.LastName); len(v) > 0 {
+			return weakPasswordErr(c, v)
 		}
 
-		hash, err := security.HashPassword(req.NewPassword)
+		err = uow.Do(c.UserContext(), func(r domain.Repos) error {
+			if _, err := r.Codes.Consume(c.UserContext(), u.ID, domain.CodeReset, req.Code); err != nil {
+				switch {
+				case errors.Is(err, apperrors.ErrCodeExpired):
+					return apperrors.ErrCodeExpired.WithMessageID("reset_code_expired")
+				case errors.Is(err, apperrors.ErrCodeInvalid):
+					return apperrors.ErrCodeInvalid.WithMessageID("reset_code_invalid")
+				default:
+					return apperrors.ErrInternal.Wrap(err)
+				}
+			}
+
+			hash, err := security.HashPassword(req.NewPassword)
+			if err != nil {
+				return apperrors.ErrInternal.WithMessageID("password_hash_failed").Wrap(err)
+			}
+			if err := r.Users.UpdatePassword(c.UserContext(), u.ID, hash); err != nil {
+				return apperrors.ErrInternal.WithMessageID("password_reset_failed").Wrap(err)
+			}
+
+			// Сбросить все активные сессии (UC-3, шаг 11)
+			if _, err := r.Sessions.RevokeAll(c.UserContext(), u.ID); err != nil {
+				return apperrors.ErrInternal.WithMessageID("password_reset_failed").Wrap(err)
+			}
+			return nil
+		})
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error_code": "SERVER_ERROR", "message": "Не удалось обработать пароль"})
+			return err
 		}
-		if err := userRepo.UpdatePassword(u.ID, hash); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error_code": "SERVER_ERROR", "message": "Не удалось сбросить пароль"})
-		}
-
-		// Сбросить все активные сессии (UC-3, шаг 11)
-		_, _ = sessions.RevokeAll(u.ID)
 
-		return c.JSON(fiber.Map{"message": "Пароль успешно сброшен"})
+		return c.JSON(fiber.Map{"message": i18n.Msg(c, "password_reset_success")})
 	}
 }
@@ -1,14 +1,20 @@
 package http
 
 import (
+	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"auth/internal/modules/auth/activity"
 	"auth/internal/modules/auth/domain"
+	"auth/internal/modules/auth/export"
 	"auth/internal/modules/auth/infra" // in-memory
 	pg "auth/internal/modules/auth/infra/pg"
+	"auth/internal/platform/clock"
+	"auth/internal/platform/geo"
+	"auth/internal/platform/health"
 	plathttp "auth/internal/platform/http"
 	"auth/internal/platform/notify"
 	"auth/internal/platform/security"
@@ -16,25 +22,109 @@ import (
 
 // Module wires up dependencies for the auth HTTP module.
 type Module struct {
+	db          *pgxpool.Pool // nil для in-memory репозиториев
 	userRepo    domain.UserRepo
 	codeRepo    domain.CodeRepo
 	sessionRepo domain.SessionRepo
+	auditRepo   domain.AuditRepo
+	exportRepo  domain.ExportRepo
+	uow         domain.UnitOfWork // многошаговые сценарии в одной транзакции
 	jwtSecret   []byte
 	accessTTL   time.Duration
+	signer      *security.Signer // подпись ссылок из писем
+	clock       clock.Clock      // сроки кодов, сессий и токенов
+	random      *security.Random // коды подтверждения и refresh-токены
 
-	mailer *notify.Mailer // << добавили
+	mailer notify.Sender // << добавили
+	geo    geo.Locator
+
+	pwPolicy      security.PasswordPolicy
+	sessionPolicy domain.SessionPolicy
+
+	deletionGrace time.Duration
+	purgeInterval time.Duration
+
+	// очистка истёкших кодов и сессий
+	cleanupInterval  time.Duration
+	cleanupBatch     int
+	codeRetention    time.Duration
+	sessionRetention time.Duration
+
+	publicBaseURL string // внешний адрес API для ссылок в письмах
+	exportLinkTTL time.Duration
+	exportsOnce   sync.Once
+	exports       *export.Service
+
+	activityFlush    time.Duration // как часто пишем last_active в БД
+	activityThrottle time.Duration // не чаще одной отметки на сессию
+	activityOnce     sync.Once
+	activity         *activity.Tracker
+
+	bg sync.WaitGroup // фоновые задачи из Start
+}
+
+func (m *Module) WithMailer(n notify.Sender) *Module { m.mailer = n; return m }
+
+// WithClock задаёт часы для сроков кодов, сессий, токенов и ссылок из писем.
+func (m *Module) WithClock(c clock.Clock) *Module {
+	m.clock = c
+	m.signer.WithClock(c)
+	return m
+}
+
+// WithRandom задаёт источник кодов подтверждения и refresh-токенов.
+func (m *Module) WithRandom(r *security.Random) *Module { m.random = r; return m }
+
+func (m *Module) WithGeo(l geo.Locator) *Module { m.geo = l; return m }
+
+func (m *Module) WithPasswordPolicy(p security.PasswordPolicy) *Module { m.pwPolicy = p; return m }
+
+func (m *Module) WithSessionPolicy(p domain.SessionPolicy) *Module { m.sessionPolicy = p; return m }
+
+// WithPublicBaseURL задаёт внешний адрес API, из которого строятся ссылки в письмах.
+func (m *Module) WithPublicBaseURL(u string) *Module { m.publicBaseURL = u; return m }
+
+// WithExport задаёт срок жизни выгрузки персональных данных и ссылки на её скачивание.
+func (m *Module) WithExport(linkTTL time.Duration) *Module { m.exportLinkTTL = linkTTL; return m }
+
+// exportService создаётся при первом обращении, когда mailer и настройки уже заданы.
+func (m *Module) exportService() *export.Service {
+	m.exportsOnce.Do(func() {
+		m.exports = export.NewService(m.userRepo, m.sessionRepo, m.codeRepo, m.auditRepo, m.exportRepo,
+			m.mailer, m.signer, m.clock, m.publicBaseURL, m.exportLinkTTL)
+	})
+	return m.exports
+}
+
+// WithSessionActivity задаёт период записи last_active и минимальный интервал между отметками одной сессии.
+func (m *Module) WithSessionActivity(flush, throttle time.Duration) *Module {
+	m.activityFlush, m.activityThrottle = flush, throttle
+	return m
+}
+
+func (m *Module) activityTracker() *activity.Tracker {
+	m.activityOnce.Do(func() {
+		m.activity = activity.NewTracker(m.sessionRepo, m.activityFlush, m.activityThrottle, m.clock)
+	})
+	return m.activity
 }
 
-func (m *Module) WithMailer(n *notify.Mailer) *Module { m.mailer = n; return m }
+// WithCleanup задаёт период очистки, размер пачки и сколько хранить истёкшие коды и сессии.
+func (m *Module) WithCleanup(interval time.Duration, batch int, codeRetention, sessionRetention time.Duration) *Module {
+	m.cleanupInterval, m.cleanupBatch = interval, batch
+	m.codeRetention, m.sessionRetention = codeRetention, sessionRetention
+	return m
+}
+
+// WithAccountDeletion задаёт срок восстановления удалённого аккаунта и период фоновой очистки.
+func (m *Module) WithAccountDeletion(grace, purgeInterval time.Duration) *Module {
+	m.deletionGrace, m.purgeInterval = grace, purgeInterval
+	return m
+}
 
 func NewModule() *Module {
-	return &Module{
-		userRepo:    infra.NewMemUserRepo(),
-		codeRepo:    infra.NewMemCodeRepo(),
-		sessionRepo: infra.NewMemSessionRepo(),
-		jwtSecret:   []byte("super-secret"),
-		accessTTL:   15 * time.Minute,
-	}
+	repos := infra.NewMemRepos(clock.System{}, nil)
+	return NewModuleRepos(repos, infra.NewMemUnitOfWork(repos))
 }
 
 // NewModulePG creates PG-based repos.
@@ -42,63 +132,127 @@ func NewModulePG(db *pgxpool.Pool, jwtSecret string, accessTTL time.Duration) *M
 	if accessTTL == 0 {
 		accessTTL = 15 * time.Minute
 	}
+	m := NewModuleRepos(domain.Repos{
+		Users:    pg.NewUserRepo(db),
+		Codes:    pg.NewCodeRepo(db),
+		Sessions: pg.NewSessionRepo(db),
+		Audit:    pg.NewAuditRepo(db),
+		Exports:  pg.NewExportRepo(db),
+	}, pg.NewUnitOfWork(db))
+	m.db = db
+	m.jwtSecret = []byte(jwtSecret)
+	m.accessTTL = accessTTL
+	m.signer = security.NewSigner(jwtSecret)
+	return m
+}
+
+// NewModuleRepos собирает модуль над готовыми репозиториями (например, in-memory
+// с управляемыми часами в тестах); остальные настройки — по умолчанию.
+func NewModuleRepos(repos domain.Repos, uow domain.UnitOfWork) *Module {
 	return &Module{
-		userRepo:    pg.NewUserRepo(db),
-		codeRepo:    pg.NewCodeRepo(db),
-		sessionRepo: pg.NewSessionRepo(db),
-		jwtSecret:   []byte(jwtSecret),
-		accessTTL:   accessTTL,
+		userRepo:    repos.Users,
+		codeRepo:    repos.Codes,
+		sessionRepo: repos.Sessions,
+		auditRepo:   repos.Audit,
+		exportRepo:  repos.Exports,
+		uow:         uow,
+		jwtSecret:   []byte("super-secret"),
+		accessTTL:   15 * time.Minute,
+		signer:      security.NewSigner("super-secret"),
+		clock:       clock.System{},
+		random:      security.CryptoRandom,
+		geo:         geo.Noop{},
+		pwPolicy:    security.DefaultPasswordPolicy(),
+
+		sessionPolicy: domain.DefaultSessionPolicy(),
+
+		deletionGrace: 30 * 24 * time.Hour,
+		purgeInterval: time.Hour,
+
+		cleanupInterval:  time.Hour,
+		cleanupBatch:     1000,
+		codeRetention:    7 * 24 * time.Hour,
+		sessionRetention: 30 * 24 * time.Hour,
+
+		publicBaseURL: "http://localhost:8081",
+		exportLinkTTL: 24 * time.Hour,
+
+		activityFlush:    30 * time.Second,
+		activityThrottle: time.Minute,
+	}
+}
+
+// RegisterHealth добавляет проверки зависимостей модуля для /readyz.
+func (m *Module) RegisterHealth(r *health.Registry) {
+	if m.db != nil {
+		r.Register("postgres", health.Ping(m.db))
+	}
+	if p, ok := m.mailer.(health.Pinger); ok {
+		r.Register("smtp", health.Ping(p))
 	}
 }
 
 func (m *Module) Register(r fiber.Router) {
-	jwtMgr := security.NewJWTManager(string(m.jwtSecret), m.accessTTL)
+	jwtMgr := security.NewJWTManager(string(m.jwtSecret), m.accessTTL).WithClock(m.clock)
+	devices := &deviceTracker{
+		mailer: m.mailer, signer: m.signer, codes: m.codeRepo, random: m.random,
+		baseURL: m.publicBaseURL, geo: m.geo, policy: m.sessionPolicy, clock: m.clock,
+	}
 
 	// -------- public --------
-	r.Post("/sign-up", SignUpHandler(m.userRepo, m.codeRepo, m.mailer))
-	r.Post("/sign-up/resend", SignUpResendHandler(m.userRepo, m.codeRepo, m.mailer))
-	r.Post("/sign-up/confirm", SignUpConfirmHandler(m.userRepo, m.codeRepo))
-	r.Post("/sign-in", SignInHandler(m.userRepo, m.sessionRepo, m.codeRepo, m.mailer, jwtMgr))
-	r.Post("/forgot-password", ForgotPasswordHandler(m.userRepo, m.codeRepo))
-	r.Post("/forgot-password/resend", ForgotPasswordResendHandler(m.userRepo, m.codeRepo))
-	r.Post("/reset-password", ResetPasswordHandler(m.userRepo, m.codeRepo, m.sessionRepo))
+	r.Post("/sign-up", trackFlow(signUps), SignUpHandler(m.uow, m.mailer, m.pwPolicy, m.clock, m.random))
+	r.Post("/sign-up/resend", SignUpResendHandler(m.userRepo, m.codeRepo, m.mailer, m.clock, m.random))
+	r.Post("/sign-up/confirm", trackFlow(signUpConfirmations), SignUpConfirmHandler(m.userRepo, m.codeRepo))
+	r.Post("/sign-in", trackFlow(signIns, "password"), SignInHandler(m.userRepo, m.sessionRepo, m.codeRepo, m.mailer, jwtMgr, devices, m.clock, m.random))
+	r.Post("/forgot-password", ForgotPasswordHandler(m.userRepo, m.codeRepo, m.clock, m.random))
+	r.Post("/forgot-password/resend", ForgotPasswordResendHandler(m.userRepo, m.codeRepo, m.clock, m.random))
+	r.Post("/reset-password", ResetPasswordHandler(m.userRepo, m.uow, m.pwPolicy))
 	// OAuth провайдер (один раз, без дубликатов)
-	r.Post("/auth/:provider", OAuthSignInHandler(m.userRepo, m.sessionRepo, jwtMgr))
-	r.Post("/refresh", RefreshHandler(m.sessionRepo, m.userRepo, jwtMgr))
-	r.Post("/sign-in/2fa", SignIn2FAHandler(m.userRepo, m.codeRepo, m.sessionRepo, jwtMgr))
+	r.Post("/auth/:provider", trackFlow(signIns, "oauth"), OAuthSignInHandler(m.userRepo, m.sessionRepo, m.uow, jwtMgr, devices, m.random))
+	r.Post("/refresh", trackFlow(refreshes), RefreshHandler(m.sessionRepo, m.userRepo, jwtMgr, m.sessionPolicy, m.clock, m.random))
+	r.Post("/sign-in/2fa", trackFlow(signIns, "2fa"), SignIn2FAHandler(m.userRepo, m.codeRepo, m.sessionRepo, jwtMgr, devices, m.random))
+	r.Get("/sign-in/not-me", NotMeConfirmHandler(m.signer))
+	r.Post("/sign-in/not-me", NotMeHandler(m.uow, m.signer))
 	r.Get("/debug/send-mail", DebugSendMailHandler(m.mailer))
+	r.Get("/user/export/:export_id/download", DownloadExportHandler(m.exportService()))
 
 	// -------- protected --------
-	protected := r.Group("", plathttp.JWTAuth(m.jwtSecret))
-	protected.Get("/user/devices", ListDevicesHandler(m.sessionRepo))
+	protected := r.Group("", plathttp.JWTAuth(m.jwtSecret, m.clock), SessionActivity(m.activityTracker()))
+	protected.Get("/user/devices", ListDevicesHandler(m.sessionRepo, m.geo, m.sessionPolicy, m.clock))
 	protected.Get("/user", GetProfileHandler(m.userRepo))
 	protected.Delete("/user/devices/:device_id", DeleteDeviceHandler(m.sessionRepo))
 	protected.Delete("/user/devices/others", DeleteOtherDevicesHandler(m.sessionRepo))
-	protected.Delete("/user", DeleteUserHandler(m.userRepo))
+	protected.Delete("/user", DeleteUserHandler(m.userRepo, m.codeRepo, m.sessionRepo, m.deletionGrace, m.clock))
+	protected.Post("/user/delete/code", RequestDeletionCodeHandler(m.userRepo, m.codeRepo, m.mailer, m.clock, m.random))
+	protected.Post("/user/export", RequestExportHandler(m.exportService(), m.auditRepo))
 	protected.Delete("/session", DeleteCurrentSessionHandler(m.sessionRepo))
 	protected.Patch("/user", UpdateProfileHandler(m.userRepo))
+	protected.Post("/user/password", ChangePasswordHandler(m.userRepo, m.sessionRepo, m.mailer, m.pwPolicy))
 	protected.Post("/user/2fa/enable", Enable2FAHandler(m.userRepo))
 	protected.Post("/user/2fa/disable", Disable2FAHandler(m.userRepo))
 
 	// -------- совместимость под /auth/* --------
 	auth := r.Group("/auth")
 	auth.Get("/ping", func(c *fiber.Ctx) error { return c.JSON(fiber.Map{"module": "auth", "ok": true}) })
-	auth.Post("/sign-up", SignUpHandler(m.userRepo, m.codeRepo, m.mailer))
-	auth.Post("/sign-up/confirm", SignUpConfirmHandler(m.userRepo, m.codeRepo))
-	auth.Post("/sign-up/resend", SignUpResendHandler(m.userRepo, m.codeRepo, m.mailer))
-	auth.Post("/sign-in", SignInHandler(m.userRepo, m.sessionRepo, m.codeRepo, m.mailer, jwtMgr))
-	auth.Post("/forgot-password", ForgotPasswordHandler(m.userRepo, m.codeRepo))
-	auth.Post("/forgot-password/resend", ForgotPasswordResendHandler(m.userRepo, m.codeRepo))
-	auth.Post("/reset-password", ResetPasswordHandler(m.userRepo, m.codeRepo, m.sessionRepo))
-	auth.Post("/refresh", RefreshHandler(m.sessionRepo, m.userRepo, jwtMgr))
-	auth.Post("/sign-in/2fa", SignIn2FAHandler(m.userRepo, m.codeRepo, m.sessionRepo, jwtMgr))
+	auth.Post("/sign-up", trackFlow(signUps), SignUpHandler(m.uow, m.mailer, m.pwPolicy, m.clock, m.random))
+	auth.Post("/sign-up/confirm", trackFlow(signUpConfirmations), SignUpConfirmHandler(m.userRepo, m.codeRepo))
+	auth.Post("/sign-up/resend", SignUpResendHandler(m.userRepo, m.codeRepo, m.mailer, m.clock, m.random))
+	auth.Post("/sign-in", trackFlow(signIns, "password"), SignInHandler(m.userRepo, m.sessionRepo, m.codeRepo, m.mailer, jwtMgr, devices, m.clock, m.random))
+	auth.Post("/forgot-password", ForgotPasswordHandler(m.userRepo, m.codeRepo, m.clock, m.random))
+	auth.Post("/forgot-password/resend", ForgotPasswordResendHandler(m.userRepo, m.codeRepo, m.clock, m.random))
+	auth.Post("/reset-password", ResetPasswordHandler(m.userRepo, m.uow, m.pwPolicy))
+	auth.Post("/refresh", trackFlow(refreshes), RefreshHandler(m.sessionRepo, m.userRepo, jwtMgr, m.sessionPolicy, m.clock, m.random))
+	auth.Post("/sign-in/2fa", trackFlow(signIns, "2fa"), SignIn2FAHandler(m.userRepo, m.codeRepo, m.sessionRepo, jwtMgr, devices, m.random))
 	// тут НЕ дублируем /:provider второй раз
-	authProtected := auth.Group("", plathttp.JWTAuth(m.jwtSecret))
-	authProtected.Get("/user/devices", ListDevicesHandler(m.sessionRepo))
+	authProtected := auth.Group("", plathttp.JWTAuth(m.jwtSecret, m.clock), SessionActivity(m.activityTracker()))
+	authProtected.Get("/user/devices", ListDevicesHandler(m.sessionRepo, m.geo, m.sessionPolicy, m.clock))
 	authProtected.Delete("/user/devices/:device_id", DeleteDeviceHandler(m.sessionRepo))
 	authProtected.Delete("/user/devices/others", DeleteOtherDevicesHandler(m.sessionRepo))
 	authProtected.Delete("/session", DeleteCurrentSessionHandler(m.sessionRepo))
 	authProtected.Get("/user", GetProfileHandler(m.userRepo))
 	authProtected.Patch("/user", UpdateProfileHandler(m.userRepo))
-	authProtected.Delete("/user", DeleteUserHandler(m.userRepo))
+	authProtected.Post("/user/password", ChangePasswordHandler(m.userRepo, m.sessionRepo, m.mailer, m.pwPolicy))
+	authProtected.Delete("/user", DeleteUserHandler(m.userRepo, m.codeRepo, m.sessionRepo, m.deletionGrace, m.clock))
+	authProtected.Post("/user/delete/code", RequestDeletionCodeHandler(m.userRepo, m.codeRepo, m.mailer, m.clock, m.random))
+	authProtected.Post("/user/export", RequestExportHandler(m.exportService(), m.auditRepo))
 }
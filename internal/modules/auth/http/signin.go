@@ -1,21 +1,29 @@
 package http
 
 import (
-	"log"
+	"context"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 
 	"auth/internal/modules/auth/domain"
+	"auth/internal/platform/clock"
+	apperrors "auth/internal/platform/errors"
+	"auth/internal/platform/i18n"
+	"auth/internal/platform/logging"
 	"auth/internal/platform/notify"
 	"auth/internal/platform/security"
+	"auth/internal/platform/tracing"
 )
 
 type signInReq struct {
 	Email      string `json:"email"`
 	Password   string `json:"password"`
 	DeviceName string `json:"device_name"`
+	DeviceID   string `json:"device_id"` // стабильный id устройства, генерирует клиент
+	Restore    bool   `json:"restore"`   // восстановить аккаунт, запланированный к удалению
+	RememberMe bool   `json:"remember_me"`
 }
 
 type signInResp struct {
@@ -31,141 +39,147 @@ func SignInHandler(
 	userRepo domain.UserRepo,
 	sessions domain.SessionRepo,
 	codeRepo domain.CodeRepo, // ← было VerificationCodeRepo
-	mailer *notify.Mailer, // ← было domain.Mailer
+	mailer notify.Sender, // ← было domain.Mailer
 	jwtMgr *security.JWTManager,
+	devices *deviceTracker,
+	clk clock.Clock,
+	rnd *security.Random,
 ) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var req signInReq
 		if err := c.BodyParser(&req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_FIELDS",
-				"message":    "Некорректные данные",
-			})
+			return apperrors.ErrInvalidFields
 		}
 
 		req.Email = strings.ToLower(strings.TrimSpace(req.Email))
 
-		u, err := userRepo.GetByEmail(req.Email)
+		u, err := userRepo.GetByEmail(c.UserContext(), req.Email)
 		if err != nil || u == nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_CREDENTIALS",
-				"message":    "Некорректный email или пароль",
-			})
+			return errInvalidCredentials
 		}
 
 		// Проверка блокировки
 		if u.IsBlocked {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error_code": "ACCOUNT_BLOCKED",
-				"message":    "Аккаунт заблокирован",
-			})
+			return apperrors.New(fiber.StatusForbidden, "ACCOUNT_BLOCKED")
 		}
 
 		// Проверка подтверждения email
 		if !u.EmailConfirmed {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "EMAIL_NOT_CONFIRMED",
-				"message":    "Подтвердите email перед входом",
-			})
+			return apperrors.New(fiber.StatusBadRequest, "EMAIL_NOT_CONFIRMED")
 		}
 
-		// Проверка пароля
-		ok, _ := security.CheckPassword(*u.PasswordHash, req.Password)
+		// Проверка пароля (у OAuth-аккаунтов пароля нет)
+		if u.PasswordHash == nil {
+			return errInvalidCredentials
+		}
+		_, span := tracing.Start(c.UserContext(), "password.verify")
+		ok, rehash, err := security.CheckPassword(*u.PasswordHash, req.Password)
+		tracing.End(span, err)
+		if err != nil {
+			logging.FromFiber(c).Error("check password", "user_id", u.ID, "err", err)
+		}
 		if !ok {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_CREDENTIALS",
-				"message":    "Некорректный email или пароль",
-			})
+			return errInvalidCredentials
+		}
+
+		// После «это был не я» вход по паролю закрыт до сброса пароля
+		if u.PasswordResetRequired {
+			return errPasswordResetRequired
+		}
+
+		// Хеш в устаревшем формате или со слабыми параметрами — перехешируем, пока знаем пароль.
+		// Ошибка не мешает входу: попробуем снова при следующем логине.
+		if rehash {
+			if h, err := security.HashPassword(req.Password); err != nil {
+				logging.FromFiber(c).Error("rehash password", "user_id", u.ID, "err", err)
+			} else if err := userRepo.UpdatePasswordHash(c.UserContext(), u.ID, h); err != nil {
+				logging.FromFiber(c).Error("store rehashed password", "user_id", u.ID, "err", err)
+			}
+		}
+
+		// Аккаунт запланирован к удалению: вход возможен только с восстановлением
+		if u.DeletedAt != nil && !req.Restore {
+			return pendingDeletionErr(u)
 		}
 
 		// 🔐 Проверка: включена ли 2FA?
 		if u.TwoFAEnabled {
-			code, err := security.RandomDigits(6)
+			code, err := rnd.Digits(6)
 			if err != nil {
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"error_code": "SERVER_ERROR",
-					"message":    "Не удалось сгенерировать код",
-				})
+				return apperrors.ErrInternal.WithMessageID("code_generate_failed").Wrap(err)
 			}
 
 			// Сохраняем код для последующей проверки
-			err = codeRepo.Save(domain.VerificationCode{
+			err = codeRepo.Save(c.UserContext(), domain.VerificationCode{
 				UserID:    u.ID,
 				Kind:      domain.Code2FA,
 				Code:      code,
-				ExpiresAt: time.Now().Add(10 * time.Minute),
+				ExpiresAt: clk.Now().Add(10 * time.Minute),
 				SentTo:    u.Email,
 			})
 			if err != nil {
-				log.Printf("failed to save 2FA code: %v", err)
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"error_code": "SERVER_ERROR",
-					"message":    "Не удалось сохранить код подтверждения",
-				})
+				return apperrors.ErrInternal.WithMessageID("confirmation_code_save_failed").Wrap(err)
 			}
 
 			// Отправляем код на email (асинхронно)
 			if mailer != nil {
+				// fiber.Ctx после возврата из обработчика переиспользуется
+				ctx := context.WithoutCancel(c.UserContext())
 				go func() {
-					if err := mailer.Send2FACode(c.Context(), u.Email, code); err != nil {
-						log.Printf("failed to send 2FA email to %s: %v", u.Email, err)
+					if err := mailer.Send2FACode(ctx, u.Email, code); err != nil {
+						logging.FromContext(ctx).Error("send 2FA email", "user_id", u.ID, "err", err)
 					}
 				}()
 			}
 
 			// Возвращаем ответ: 2FA требуется, токены не выданы
 			return c.JSON(signInResp{
-				Message:     "Требуется подтверждение двухфакторной аутентификации",
+				Message:     i18n.Msg(c, "signin_2fa_required"),
 				Requires2FA: true,
 			})
 		}
 
 		// 🟢 Если 2FA НЕ включена — продолжаем обычный вход
 
+		// при 2FA восстановление выполняется только после проверки кода
+		if u.DeletedAt != nil {
+			if err := userRepo.Restore(c.UserContext(), u.ID); err != nil {
+				return apperrors.ErrInternal.WithMessageID("account_restore_failed").Wrap(err)
+			}
+		}
+
 		// Генерируем refresh token
-		rt, _, err := security.IssueRefresh()
+		rt, err := rnd.RefreshToken()
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR",
-				"message":    "Не удалось создать refresh_token",
-			})
+			return apperrors.ErrInternal.WithMessageID("refresh_token_failed").Wrap(err)
 		}
 
 		// Хешируем refresh token для хранения
 		rth := security.HashToken(rt)
-		ip := c.IP()
-		ua := c.Get("User-Agent")
-		dev := req.DeviceName
+		dev := devices.fromRequest(c, req.DeviceName, req.DeviceID, req.RememberMe)
+		known := devices.knownDevice(c.UserContext(), sessions, u.ID, dev)
 
-		s := domain.Session{
-			UserID:           u.ID,
-			RefreshTokenHash: rth,
-			DeviceName:       &dev,
-			IPAddress:        &ip,
-			UserAgent:        &ua,
+		sess, err := devices.createSession(c.UserContext(), sessions, u, dev, rth)
+		if err != nil {
+			return err
 		}
 
-		sess, err := sessions.Create(s)
-		if err != nil {
-			log.Printf("create session error: %v", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR",
-				"message":    "Не удалось создать сессию",
-			})
+		if !known {
+			devices.alert(c.UserContext(), u, sess, dev)
 		}
 
+		// пользователь подтверждён — дальше отвечаем на его языке
+		i18n.SetLang(c, u.Locale)
+
 		// Генерируем access token с включённым session_id (sid)
-		at, exp, err := jwtMgr.IssueAccess(u.ID, string(u.Role), sess.ID)
+		at, exp, err := jwtMgr.IssueAccess(u.ID, string(u.Role), sess.ID, u.Locale)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR",
-				"message":    "Не удалось создать access_token",
-			})
+			return apperrors.ErrInternal.WithMessageID("access_token_failed").Wrap(err)
 		}
 
 		// Возвращаем токены
 		return c.JSON(signInResp{
-			Message:      "Вход успешен",
+			Message:      i18n.Msg(c, "signin_success"),
 			AccessToken:  at,
 			RefreshToken: rt,
 			ExpiresAt:    exp.UTC().Format(time.RFC3339),
@@ -173,3 +187,13 @@ func SignInHandler(
 		})
 	}
 }
+
+// errPendingDeletion — вход в аккаунт, запланированный к удалению.
+var errPendingDeletion = apperrors.New(fiber.StatusForbidden, "ACCOUNT_PENDING_DELETION")
+
+func pendingDeletionErr(u *domain.User) error {
+	if u.PurgeAfter == nil {
+		return errPendingDeletion
+	}
+	return errPendingDeletion.WithDetails(fiber.Map{"purge_after": u.PurgeAfter.UTC().Format(time.RFC3339)})
+}
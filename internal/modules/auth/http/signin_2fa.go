@@ -6,6 +6,8 @@ import (
 	"github.com/gofiber/fiber/v2"
 
 	"auth/internal/modules/auth/domain"
+	apperrors "auth/internal/platform/errors"
+	"auth/internal/platform/i18n"
 	"auth/internal/platform/security"
 )
 
@@ -13,6 +15,9 @@ type signIn2FAReq struct {
 	Email      string `json:"email"`
 	Code       string `json:"code"`
 	DeviceName string `json:"device_name"`
+	DeviceID   string `json:"device_id"`
+	Restore    bool   `json:"restore"`
+	RememberMe bool   `json:"remember_me"` // тот же флаг, что и на первом шаге входа
 }
 
 func SignIn2FAHandler(
@@ -20,65 +25,65 @@ func SignIn2FAHandler(
 	codeRepo domain.CodeRepo,
 	sessions domain.SessionRepo,
 	jwtMgr *security.JWTManager,
+	devices *deviceTracker,
+	rnd *security.Random,
 ) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var req signIn2FAReq
 		if err := c.BodyParser(&req); err != nil || len(req.Code) != 6 {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_FIELDS",
-				"message":    "Некорректные данные",
-			})
+			return apperrors.ErrInvalidFields
 		}
 
-		u, err := userRepo.GetByEmail(req.Email)
+		u, err := userRepo.GetByEmail(c.UserContext(), req.Email)
 		if err != nil || u == nil || !u.TwoFAEnabled {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_STATE",
-				"message":    "Пользователь не найден или 2FA не включена",
-			})
+			return errInvalidState.WithMessageID("twofa_not_pending")
 		}
 
 		// проверяем код
-		if _, err := codeRepo.Consume(u.ID, domain.Code2FA, req.Code); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_CODE",
-				"message":    "Некорректный или истёкший код",
-			})
+		if _, err := codeRepo.Consume(c.UserContext(), u.ID, domain.Code2FA, req.Code); err != nil {
+			return apperrors.ErrCodeInvalid.WithMessageID("code_invalid_or_expired")
+		}
+		// код мог быть выдан до перехода по ссылке «это был не я»
+		if u.PasswordResetRequired {
+			return errPasswordResetRequired
+		}
+
+		if u.DeletedAt != nil {
+			if !req.Restore {
+				return pendingDeletionErr(u)
+			}
+			if err := userRepo.Restore(c.UserContext(), u.ID); err != nil {
+				return apperrors.ErrInternal.WithMessageID("account_restore_failed").Wrap(err)
+			}
 		}
 
 		// создаём refresh + сессию
-		rt, _, err := security.IssueRefresh()
+		rt, err := rnd.RefreshToken()
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR", "message": "Не удалось создать refresh",
-			})
+			return apperrors.ErrInternal.WithMessageID("refresh_token_failed").Wrap(err)
 		}
 		rth := security.HashToken(rt)
-		ip, ua, dev := c.IP(), c.Get("User-Agent"), req.DeviceName
-		sess, err := sessions.Create(domain.Session{
-			UserID:           u.ID,
-			RefreshTokenHash: rth,
-			DeviceName:       &dev,
-			IPAddress:        &ip,
-			UserAgent:        &ua,
-			ExpiresAt:        time.Now().Add(30 * 24 * time.Hour),
-		})
+		dev := devices.fromRequest(c, req.DeviceName, req.DeviceID, req.RememberMe)
+		known := devices.knownDevice(c.UserContext(), sessions, u.ID, dev)
+		sess, err := devices.createSession(c.UserContext(), sessions, u, dev, rth)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR", "message": "Не удалось создать сессию",
-			})
+			return err
+		}
+		if !known {
+			devices.alert(c.UserContext(), u, sess, dev)
 		}
 
+		// пользователь подтверждён — дальше отвечаем на его языке
+		i18n.SetLang(c, u.Locale)
+
 		// создаём access
-		at, exp, err := jwtMgr.IssueAccess(u.ID, string(u.Role), sess.ID)
+		at, exp, err := jwtMgr.IssueAccess(u.ID, string(u.Role), sess.ID, u.Locale)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR", "message": "Не удалось создать access_token",
-			})
+			return apperrors.ErrInternal.WithMessageID("access_token_failed").Wrap(err)
 		}
 
 		return c.JSON(fiber.Map{
-			"message":       "Вход завершён",
+			"message":       i18n.Msg(c, "signin_2fa_success"),
 			"access_token":  at,
 			"refresh_token": rt,
 			"expires_at":    exp.UTC().Format(time.RFC3339),
@@ -1,15 +1,22 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/mail"
+	"reflect"
 	"strings"
 	"time"
 
 	"auth/internal/modules/auth/domain"
+	"auth/internal/platform/clock"
+	apperrors "auth/internal/platform/errors"
+	"auth/internal/platform/i18n"
+	"auth/internal/platform/logging"
 	"auth/internal/platform/notify"
 	"auth/internal/platform/security"
+	"auth/internal/platform/tracing"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -17,7 +24,7 @@ import (
 
 type signUpReq struct {
 	Email            string  `json:"email" validate:"required,email"`
-	Password         string  `json:"password" validate:"required,min=8,max=50"`
+	Password         string  `json:"password" validate:"required"` // остальные правила — в security.PasswordPolicy
 	FirstName        string  `json:"first_name" validate:"required,min=2,max=50"`
 	LastName         string  `json:"last_name" validate:"required,min=2,max=50"`
 	Role             string  `json:"role" validate:"required,oneof=journalist guide restaurant"`
@@ -25,27 +32,66 @@ type signUpReq struct {
 	PrivacyAgreement bool    `json:"privacy_agreement" validate:"eq=true"`
 }
 
-var validate = validator.New()
+var validate = newValidator()
+
+// newValidator — валидатор, который называет поля по json-тегам, как их видит клиент.
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}
+
+// validationErr переводит ошибки validator в VALIDATION_ERROR с деталями по полям.
+func validationErr(err error) error {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return apperrors.ErrValidation
+	}
+	fields := make([]apperrors.FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		// текст подставит ErrorHandler на языке запроса
+		fields = append(fields, apperrors.FieldError{Field: fe.Field(), Rule: fe.Tag()})
+	}
+	return apperrors.ErrValidation.WithFields(fields...)
+}
+
+// errWeakPassword — нарушение политики паролей, с деталями по каждому правилу.
+var errWeakPassword = apperrors.New(fiber.StatusBadRequest, "WEAK_PASSWORD")
+
+func weakPasswordErr(c *fiber.Ctx, violations []security.PasswordViolation) error {
+	for i, v := range violations {
+		violations[i].Message = i18n.Msg(c, "password."+v.Rule)
+	}
+	return errWeakPassword.WithDetails(violations)
+}
 
 type signUpResp struct {
 	Message string `json:"message"`
 	UserID  string `json:"user_id"`
 }
 
+// SignUpHandler создаёт пользователя и код в одной транзакции, затем отправляет
+// письмо — уже без открытой транзакции. Письмо не ушло — пользователь удаляется
+// (вместе с кодом), чтобы email не остался занят неподтверждаемой регистрацией.
 func SignUpHandler(
-	userRepo domain.UserRepo,
-	codeRepo domain.CodeRepo,
-	mailer *notify.Mailer,
+	uow domain.UnitOfWork,
+	mailer notify.Sender,
+	pwPolicy security.PasswordPolicy,
+	clk clock.Clock,
+	rnd *security.Random,
 ) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var req signUpReq
 		// Accept both snake_case and common camelCase keys from clients.
 		raw := c.Body()
 		if len(raw) == 0 {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_FIELDS",
-				"message":    "Некорректные данные",
-			})
+			return apperrors.ErrInvalidFields
 		}
 
 		// quick normalization: map frequent camelCase keys to snake_case
@@ -58,108 +104,96 @@ func SignUpHandler(
 		if err := json.Unmarshal(normalized, &req); err != nil {
 			// fallback: try BodyParser (may support form data)
 			if err2 := c.BodyParser(&req); err2 != nil {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"error_code": "INVALID_FIELDS",
-					"message":    "Некорректные данные",
-				})
+				return apperrors.ErrInvalidFields
 			}
 		}
 
 		// Валидация
 		if err := validate.Struct(req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "VALIDATION_ERROR",
-				"message":    "Ошибка валидации",
-				// Опционально: можно добавить детали err
-			})
+			return validationErr(err)
 		}
 
 		// Дополнительно: строгая проверка email
 		if _, err := mail.ParseAddress(req.Email); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_EMAIL",
-				"message":    "Некорректный формат email",
-			})
+			return errInvalidEmail
 		}
 
-		// Проверка уникальности
-		exists, err := userRepo.ExistsByEmail(strings.ToLower(req.Email))
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR",
-				"message":    "Не удалось проверить email",
-			})
-		}
-		if exists {
-			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
-				"error_code": "EMAIL_TAKEN",
-				"message":    "Email уже занят",
-			})
+		if v := pwPolicy.Validate(req.Password, req.Email, req.FirstName, req.LastName); len(v) > 0 {
+			return weakPasswordErr(c, v)
 		}
 
-		// Хеширование пароля
+		// Хеширование пароля — до транзакции, чтобы не держать её открытой
+		_, span := tracing.Start(c.UserContext(), "password.hash")
 		pwHash, err := security.HashPassword(req.Password)
+		tracing.End(span, err)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR",
-				"message":    "Не удалось обработать пароль",
-			})
+			return apperrors.ErrInternal.WithMessageID("password_hash_failed").Wrap(err)
 		}
 
-		// Создание пользователя
-		u, err := userRepo.Create(domain.CreateUserParams{
-			Email:        strings.ToLower(req.Email),
-			Phone:        req.Phone,
-			FirstName:    req.FirstName,
-			LastName:     req.LastName,
-			Role:         domain.Role(req.Role),
-			PasswordHash: &pwHash,
-		})
+		// Генерация кода подтверждения
+		code, err := rnd.Digits(6)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR",
-				"message":    "Не удалось зарегистрировать пользователя",
-			})
+			return apperrors.ErrInternal.WithMessageID("confirmation_code_generate_failed").Wrap(err)
 		}
 
-		// Генерация кода подтверждения
-		code, err := security.RandomDigits(6)
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR",
-				"message":    "Не удалось сгенерировать код подтверждения",
+		var u *domain.User
+		err = uow.Do(c.UserContext(), func(r domain.Repos) error {
+			// Проверка уникальности
+			exists, err := r.Users.ExistsByEmail(c.UserContext(), strings.ToLower(req.Email))
+			if err != nil {
+				return apperrors.ErrInternal.WithMessageID("email_check_failed").Wrap(err)
+			}
+			if exists {
+				return apperrors.ErrEmailTaken
+			}
+
+			// Создание пользователя
+			u, err = r.Users.Create(c.UserContext(), domain.CreateUserParams{
+				Email:        strings.ToLower(req.Email),
+				Phone:        req.Phone,
+				FirstName:    req.FirstName,
+				LastName:     req.LastName,
+				Role:         domain.Role(req.Role),
+				PasswordHash: &pwHash,
 			})
-		}
+			if errors.Is(err, apperrors.ErrEmailTaken) {
+				// параллельная регистрация успела раньше
+				return err
+			}
+			if err != nil {
+				return apperrors.ErrInternal.WithMessageID("signup_failed").Wrap(err)
+			}
 
-		// Сохранение кода
-		err = codeRepo.Save(domain.VerificationCode{
-			UserID:    u.ID,
-			Kind:      domain.CodeSignup,
-			Code:      code,
-			ExpiresAt: time.Now().Add(1 * time.Hour),
-			SentTo:    u.Email,
+			// Сохранение кода
+			err = r.Codes.Save(c.UserContext(), domain.VerificationCode{
+				UserID:    u.ID,
+				Kind:      domain.CodeSignup,
+				Code:      code,
+				ExpiresAt: clk.Now().Add(1 * time.Hour),
+				SentTo:    u.Email,
+			})
+			if err != nil {
+				return apperrors.ErrInternal.WithMessageID("confirmation_code_save_failed").Wrap(err)
+			}
+			return nil
 		})
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR",
-				"message":    "Не удалось сохранить код подтверждения",
-			})
+			return err
 		}
 
-		// Отправка письма с кодом
 		if mailer != nil {
-			if err := mailer.SendSignupCode(c.Context(), u.Email, code); err != nil {
-				// залогируем и вернём 500, чтобы сразу увидеть проблему
-				fmt.Printf("send mail error: %v\n", err)
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"error_code": "MAIL_SEND_ERROR",
-					"message":    "Не удалось отправить письмо с кодом",
-				})
+			if err := mailer.SendSignupCode(c.UserContext(), u.Email, code); err != nil {
+				// компенсация: запрос мог быть отменён по таймауту, удаляем без его отмены
+				ctx := context.WithoutCancel(c.UserContext())
+				if derr := uow.Do(ctx, func(r domain.Repos) error { return r.Users.Delete(ctx, u.ID) }); derr != nil {
+					logging.FromFiber(c).Error("delete user after failed signup mail", "user_id", u.ID, "err", derr)
+				}
+				return errMailSend.Wrap(err)
 			}
 		}
 
 		return c.Status(fiber.StatusCreated).JSON(signUpResp{
-			Message: "Регистрация успешна. Подтвердите email",
+			Message: i18n.Msg(c, "signup_success"),
 			UserID:  u.ID,
 		})
 	}
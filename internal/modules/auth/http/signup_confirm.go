@@ -1,13 +1,15 @@
 package http
 
 import (
+	"errors"
 	"net/mail"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
 
 	"auth/internal/modules/auth/domain"
-	"auth/internal/modules/auth/infra"
+	apperrors "auth/internal/platform/errors"
+	"auth/internal/platform/i18n"
 )
 
 type confirmReq struct {
@@ -24,69 +26,47 @@ func SignUpConfirmHandler(userRepo domain.UserRepo, codeRepo domain.CodeRepo) fi
 	return func(c *fiber.Ctx) error {
 		var req confirmReq
 		if err := c.BodyParser(&req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_FIELDS",
-				"message":    "Некорректные данные",
-			})
+			return apperrors.ErrInvalidFields
 		}
 
 		req.Email = strings.ToLower(strings.TrimSpace(req.Email))
 		req.Code = strings.TrimSpace(req.Code)
 
 		if req.Email == "" || req.Code == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_FIELDS",
-				"message":    "Email и код обязательны",
-			})
+			return apperrors.ErrInvalidFields.WithMessageID("email_and_code_required")
 		}
 		if _, err := mail.ParseAddress(req.Email); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_EMAIL",
-				"message":    "Некорректный формат email",
-			})
+			return errInvalidEmail
 		}
 		if len(req.Code) != 6 {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_CODE",
-				"message":    "Некорректный код подтверждения",
-			})
+			return apperrors.ErrCodeInvalid.WithMessageID("confirmation_code_invalid")
 		}
 
 		// находим пользователя
-		u, err := userRepo.GetByEmail(req.Email)
+		u, err := userRepo.GetByEmail(c.UserContext(), req.Email)
 		if err != nil || u == nil {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error_code": "NOT_FOUND",
-				"message":    "Пользователь не найден",
-			})
+			return apperrors.ErrNotFound.WithMessageID("user_not_found")
 		}
 
 		// пробуем погасить код
-		if _, err := codeRepo.Consume(u.ID, domain.CodeSignup, req.Code); err != nil {
-			switch err {
-			case infra.ErrCodeExpired:
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"error_code": "CODE_EXPIRED",
-					"message":    "Код подтверждения истёк",
-				})
+		if _, err := codeRepo.Consume(c.UserContext(), u.ID, domain.CodeSignup, req.Code); err != nil {
+			switch {
+			case errors.Is(err, apperrors.ErrCodeExpired):
+				return apperrors.ErrCodeExpired.WithMessageID("confirmation_code_expired")
+			case errors.Is(err, apperrors.ErrCodeInvalid):
+				return apperrors.ErrCodeInvalid.WithMessageID("confirmation_code_invalid")
 			default:
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"error_code": "INVALID_CODE",
-					"message":    "Некорректный код подтверждения",
-				})
+				return apperrors.ErrInternal.Wrap(err)
 			}
 		}
 
 		// помечаем email подтверждённым
-		if err := userRepo.ConfirmEmail(u.ID); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR",
-				"message":    "Не удалось подтвердить email",
-			})
+		if err := userRepo.ConfirmEmail(c.UserContext(), u.ID); err != nil {
+			return apperrors.ErrInternal.WithMessageID("email_confirm_failed").Wrap(err)
 		}
 
 		return c.JSON(confirmResp{
-			Message: "Email успешно подтверждён",
+			Message: i18n.Msg(c, "email_confirmed"),
 			UserID:  u.ID,
 		})
 	}
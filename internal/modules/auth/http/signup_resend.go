@@ -1,7 +1,6 @@
 package http
 
 import (
-	"fmt"
 	"net/mail"
 	"strings"
 	"time"
@@ -9,6 +8,9 @@ import (
 	"github.com/gofiber/fiber/v2"
 
 	"auth/internal/modules/auth/domain"
+	"auth/internal/platform/clock"
+	apperrors "auth/internal/platform/errors"
+	"auth/internal/platform/i18n"
 	"auth/internal/platform/notify"
 	"auth/internal/platform/security"
 )
@@ -24,90 +26,61 @@ type resendResp struct {
 func SignUpResendHandler(
 	userRepo domain.UserRepo,
 	codeRepo domain.CodeRepo,
-	mailer *notify.Mailer, // <<< добавили
+	mailer notify.Sender, // <<< добавили
+	clk clock.Clock,
+	rnd *security.Random,
 ) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var req resendReq
 		if err := c.BodyParser(&req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_FIELDS",
-				"message":    "Некорректные данные",
-			})
+			return apperrors.ErrInvalidFields
 		}
 		req.Email = strings.ToLower(strings.TrimSpace(req.Email))
 		if req.Email == "" || func() bool { _, e := mail.ParseAddress(req.Email); return e != nil }() {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "INVALID_EMAIL",
-				"message":    "Некорректный формат email",
-			})
+			return errInvalidEmail
 		}
 
-		u, err := userRepo.GetByEmail(req.Email)
+		u, err := userRepo.GetByEmail(c.UserContext(), req.Email)
 		if err != nil {
 			// differentiate DB/internal error from not-found
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR",
-				"message":    "Не удалось получить пользователя",
-			})
+			return apperrors.ErrInternal.WithMessageID("user_load_failed").Wrap(err)
 		}
 		if u == nil {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error_code": "NOT_FOUND",
-				"message":    "Пользователь не найден",
-			})
+			return apperrors.ErrNotFound.WithMessageID("user_not_found")
 		}
 		if u.EmailConfirmed {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error_code": "ALREADY_CONFIRMED",
-				"message":    "Email уже подтверждён",
-			})
+			return apperrors.New(fiber.StatusBadRequest, "ALREADY_CONFIRMED")
 		}
 
-		ok, err := codeRepo.ResendAllowed(u.ID, domain.CodeSignup)
+		ok, err := codeRepo.ResendAllowed(c.UserContext(), u.ID, domain.CodeSignup)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR",
-				"message":    "Не удалось проверить лимит отправки",
-			})
+			return apperrors.ErrInternal.WithMessageID("resend_check_failed").Wrap(err)
 		}
 		if !ok {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error_code": "RATE_LIMIT_EXCEEDED",
-				"message":    "Слишком много запросов. Попробуйте позже",
-			})
+			return errRateLimited
 		}
 
-		code, err := security.RandomDigits(6)
+		code, err := rnd.Digits(6)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR",
-				"message":    "Не удалось сгенерировать код",
-			})
+			return apperrors.ErrInternal.WithMessageID("code_generate_failed").Wrap(err)
 		}
-		if err := codeRepo.Save(domain.VerificationCode{
+		if err := codeRepo.Save(c.UserContext(), domain.VerificationCode{
 			UserID:    u.ID,
 			Kind:      domain.CodeSignup,
 			Code:      code,
-			ExpiresAt: time.Now().Add(1 * time.Hour),
+			ExpiresAt: clk.Now().Add(1 * time.Hour),
 			SentTo:    u.Email,
 		}); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error_code": "SERVER_ERROR",
-				"message":    "Не удалось сохранить код",
-			})
+			return apperrors.ErrInternal.WithMessageID("code_save_failed").Wrap(err)
 		}
 
 		// 🔔 Отправка письма
 		if mailer != nil {
-			if err := mailer.SendSignupCode(c.Context(), u.Email, code); err != nil {
-				fmt.Printf("send mail error (resend): %v\n", err)
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"error_code": "MAIL_SEND_ERROR",
-					"message":    "Не удалось отправить письмо с кодом",
-				})
+			if err := mailer.SendSignupCode(c.UserContext(), u.Email, code); err != nil {
+				return errMailSend.Wrap(err)
 			}
 		}
 
-		return c.JSON(resendResp{Message: "Код подтверждения отправлен повторно"})
+		return c.JSON(resendResp{Message: i18n.Msg(c, "confirmation_code_resent")})
 	}
 }
@@ -1,7 +1,10 @@
 package infra
 
 import (
-	"errors"
+	"context"
+	"io"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -9,61 +12,119 @@ import (
 	"github.com/google/uuid"
 
 	"auth/internal/modules/auth/domain"
+	"auth/internal/platform/clock"
+	apperrors "auth/internal/platform/errors"
 )
 
-var (
-	ErrCodeInvalid = errors.New("code_invalid")
-	ErrCodeExpired = errors.New("code_expired")
-)
+// memEnv — источники времени и идентификаторов in-memory репозиториев.
+type memEnv struct {
+	clock clock.Clock
+	rand  io.Reader // для UUID; nil — crypto/rand
+}
+
+func (e memEnv) now() time.Time { return e.clock.Now().UTC() }
+
+func (e memEnv) newID() string {
+	if e.rand == nil {
+		return uuid.New().String()
+	}
+	return uuid.Must(uuid.NewRandomFromReader(e.rand)).String()
+}
+
+var systemEnv = memEnv{clock: clock.System{}}
+
+// NewMemRepos — набор in-memory репозиториев с общими часами и источником
+// случайности для UUID (rnd nil — crypto/rand); в тестах их подменяют детерминированными.
+func NewMemRepos(clk clock.Clock, rnd io.Reader) domain.Repos {
+	env := memEnv{clock: clk, rand: rnd}
+	users, codes, sessions := newMemUserRepo(env), newMemCodeRepo(env), newMemSessionRepo(env)
+	exports, audit := newMemExportRepo(env), &memAuditRepo{memEnv: env}
+	users.cascade = []userOwned{sessions, codes, exports}
+	users.audit = audit
+	return domain.Repos{
+		Users:    users,
+		Codes:    codes,
+		Sessions: sessions,
+		Audit:    audit,
+		Exports:  exports,
+	}
+}
+
+// userOwned — хранилище записей пользователя, которые удаляются вместе с ним
+// (в PG — внешние ключи ON DELETE CASCADE).
+type userOwned interface {
+	deleteByUser(userID string)
+}
 
 type memUserRepo struct {
+	memEnv
 	mu      sync.RWMutex
 	users   map[string]*domain.User // id -> user
 	byEmail map[string]string       // email -> id
+	cascade []userOwned
+	audit   *memAuditRepo // PurgeDeleted обезличивает записи аудита, как PG
+}
+
+// remove удаляет пользователя вместе с его сессиями, кодами и выгрузками; вызывается под r.mu.
+func (r *memUserRepo) remove(u *domain.User) {
+	delete(r.users, u.ID)
+	delete(r.byEmail, u.Email)
+	for _, c := range r.cascade {
+		c.deleteByUser(u.ID)
+	}
 }
 
-func NewMemUserRepo() domain.UserRepo {
+func NewMemUserRepo() domain.UserRepo { return newMemUserRepo(systemEnv) }
+
+func newMemUserRepo(env memEnv) *memUserRepo {
 	return &memUserRepo{
+		memEnv:  env,
 		users:   make(map[string]*domain.User),
 		byEmail: make(map[string]string),
 	}
 }
 
-func (r *memUserRepo) Create(p domain.CreateUserParams) (*domain.User, error) {
+func (r *memUserRepo) Create(_ context.Context, p domain.CreateUserParams) (*domain.User, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	if _, ok := r.byEmail[p.Email]; ok {
-		return nil, errors.New("email_taken")
+	email := strings.ToLower(p.Email)
+	if _, ok := r.byEmail[email]; ok {
+		return nil, apperrors.ErrEmailTaken
 	}
-	id := uuid.New().String()
-	now := time.Now().UTC()
+	id := r.newID()
+	now := r.now()
 	u := &domain.User{
-		ID: id, Email: p.Email, Phone: p.Phone, FirstName: p.FirstName, LastName: p.LastName,
+		ID: id, Email: email, Phone: p.Phone, FirstName: p.FirstName, LastName: p.LastName,
 		Role: p.Role, PasswordHash: p.PasswordHash, CreatedAt: now, UpdatedAt: now,
 	}
 	r.users[id] = u
-	r.byEmail[p.Email] = id
-	return u, nil
+	r.byEmail[email] = id
+	return cloneUser(u), nil
+}
+
+// cloneUser — копия для вызывающего: как и в pg, изменения в ней не попадают в хранилище.
+func cloneUser(u *domain.User) *domain.User {
+	cp := *u
+	cp.Providers = append([]string{}, u.Providers...)
+	return &cp
 }
 
-// ДОБАВЬ:
-// ДОБАВЬ:
-func (r *memUserRepo) GetByID(id string) (*domain.User, error) {
+func (r *memUserRepo) GetByID(_ context.Context, id string) (*domain.User, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	u, ok := r.users[id]
 	if !ok {
-		return nil, errors.New("not_found")
+		return nil, apperrors.ErrNotFound
 	}
-	return u, nil
+	return cloneUser(u), nil
 }
 
-func (r *memUserRepo) UpdateProfile(userID string, firstName *string, lastName *string, phone *string) error {
+func (r *memUserRepo) UpdateProfile(_ context.Context, userID string, firstName *string, lastName *string, phone *string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	u, ok := r.users[userID]
 	if !ok {
-		return errors.New("not_found")
+		return apperrors.ErrNotFound
 	}
 	if firstName != nil {
 		u.FirstName = strings.TrimSpace(*firstName)
@@ -74,77 +135,81 @@ func (r *memUserRepo) UpdateProfile(userID string, firstName *string, lastName *
 	if phone != nil {
 		u.Phone = phone
 	}
-	u.UpdatedAt = time.Now().UTC()
+	u.UpdatedAt = r.now()
 	return nil
 }
 
-func (r *memUserRepo) Delete(id string) error {
+func (r *memUserRepo) Delete(_ context.Context, id string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	u, ok := r.users[id]
 	if !ok {
-		return errors.New("not_found")
-	}
-	delete(r.users, id)
-	// убрать из byEmail
-	for e, uid := range r.byEmail {
-		if uid == u.ID {
-			delete(r.byEmail, e)
-			break
-		}
+		return apperrors.ErrNotFound
 	}
+	r.remove(u)
 	return nil
 }
 
-func (r *memUserRepo) GetByEmail(email string) (*domain.User, error) {
+func (r *memUserRepo) GetByEmail(_ context.Context, email string) (*domain.User, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	id, ok := r.byEmail[email]
+	id, ok := r.byEmail[strings.ToLower(email)]
 	if !ok {
-		return nil, errors.New("not_found")
+		return nil, apperrors.ErrNotFound
 	}
-	return r.users[id], nil
+	return cloneUser(r.users[id]), nil
 }
 
-func (r *memUserRepo) ExistsByEmail(email string) (bool, error) {
+func (r *memUserRepo) ExistsByEmail(_ context.Context, email string) (bool, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	_, ok := r.byEmail[email]
+	_, ok := r.byEmail[strings.ToLower(email)]
 	return ok, nil
 }
 
-func (r *memUserRepo) ConfirmEmail(userID string) error {
+func (r *memUserRepo) ConfirmEmail(_ context.Context, userID string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	u, ok := r.users[userID]
 	if !ok {
-		return errors.New("not_found")
+		return apperrors.ErrNotFound
 	}
 	u.EmailConfirmed = true
-	u.UpdatedAt = time.Now().UTC()
+	u.UpdatedAt = r.now()
 	return nil
 }
 
 type memSessionRepo struct {
+	memEnv
 	mu       sync.RWMutex
 	sessions map[string]*domain.Session
 	byUser   map[string][]string
+	devices  map[string]map[string]bool // userID -> отпечатки устройств; DeleteExpired их не трогает
 }
 
-func NewMemSessionRepo() domain.SessionRepo {
+func NewMemSessionRepo() domain.SessionRepo { return newMemSessionRepo(systemEnv) }
+
+func newMemSessionRepo(env memEnv) *memSessionRepo {
 	return &memSessionRepo{
+		memEnv:   env,
 		sessions: make(map[string]*domain.Session),
 		byUser:   make(map[string][]string),
+		devices:  make(map[string]map[string]bool),
 	}
 }
 
-func (r *memSessionRepo) Create(s domain.Session) (*domain.Session, error) {
+func (r *memSessionRepo) Create(_ context.Context, s domain.Session) (*domain.Session, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	return r.create(s), nil
+}
+
+// create вызывается под r.mu.
+func (r *memSessionRepo) create(s domain.Session) *domain.Session {
 	if s.ID == "" {
-		s.ID = uuid.New().String()
+		s.ID = r.newID()
 	}
-	now := time.Now().UTC()
+	now := r.now()
 	s.CreatedAt = now
 	s.LastActive = now
 	if s.ExpiresAt.IsZero() { // <-- ДОБАВИЛИ
@@ -153,10 +218,41 @@ func (r *memSessionRepo) Create(s domain.Session) (*domain.Session, error) {
 	cp := s
 	r.sessions[s.ID] = &cp
 	r.byUser[s.UserID] = append(r.byUser[s.UserID], s.ID)
-	return &cp, nil
+	if s.Fingerprint != nil {
+		if r.devices[s.UserID] == nil {
+			r.devices[s.UserID] = map[string]bool{}
+		}
+		r.devices[s.UserID][*s.Fingerprint] = true
+	}
+	return &cp
 }
 
-func (r *memSessionRepo) FindByRefreshHash(hash string) (*domain.Session, error) { // <-- НОВОЕ
+func (r *memSessionRepo) CreateWithLimit(_ context.Context, s domain.Session, limit domain.SessionLimit) (*domain.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if limit.Max > 0 {
+		now := r.now()
+		var active []*domain.Session
+		for _, id := range r.byUser[s.UserID] {
+			if cur := r.sessions[id]; cur.Active(now) && cur.LastActive.After(limit.ActiveSince) {
+				active = append(active, cur)
+			}
+		}
+		if over := len(active) - limit.Max + 1; over > 0 {
+			if !limit.Evict {
+				return nil, domain.ErrSessionLimit
+			}
+			sort.Slice(active, func(i, j int) bool { return active[i].LastActive.Before(active[j].LastActive) })
+			for _, old := range active[:over] {
+				t := now.UTC()
+				old.RevokedAt = &t
+			}
+		}
+	}
+	return r.create(s), nil
+}
+
+func (r *memSessionRepo) FindByRefreshHash(_ context.Context, hash string) (*domain.Session, error) { // <-- НОВОЕ
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	for _, s := range r.sessions {
@@ -165,17 +261,96 @@ func (r *memSessionRepo) FindByRefreshHash(hash string) (*domain.Session, error)
 			return &cp, nil
 		}
 	}
-	return nil, errors.New("not_found")
+	return nil, apperrors.ErrNotFound
+}
+
+func (r *memSessionRepo) Rotate(_ context.Context, sessionID, oldHash, newHash, ip string, expiresAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.sessions[sessionID]
+	if !ok || s.RevokedAt != nil || s.RefreshTokenHash != oldHash {
+		return false, nil
+	}
+	s.RefreshTokenHash = newHash
+	s.LastActive = r.now()
+	s.ExpiresAt = expiresAt
+	if ip != "" {
+		s.IPAddress = &ip
+	}
+	return true, nil
+}
+
+func (r *memSessionRepo) Touch(_ context.Context, batch []domain.SessionTouch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range batch {
+		s, ok := r.sessions[t.SessionID]
+		if !ok || s.RevokedAt != nil {
+			continue
+		}
+		if t.At.After(s.LastActive) {
+			s.LastActive = t.At
+		}
+		if t.IP != "" {
+			ip := t.IP
+			s.IPAddress = &ip
+		}
+	}
+	return nil
 }
 
-func (r *memSessionRepo) ListByUser(userID string, page, limit int) ([]domain.Session, int, error) {
+func (r *memSessionRepo) DeleteExpired(_ context.Context, before time.Time, limit int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for id, s := range r.sessions {
+		if n >= limit {
+			break
+		}
+		if !s.ExpiresAt.Before(before) && (s.RevokedAt == nil || !s.RevokedAt.Before(before)) {
+			continue
+		}
+		delete(r.sessions, id)
+		ids := r.byUser[s.UserID]
+		for i, sid := range ids {
+			if sid == id {
+				r.byUser[s.UserID] = append(ids[:i], ids[i+1:]...)
+				break
+			}
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (r *memSessionRepo) KnownDevice(_ context.Context, userID, fingerprint string) (bool, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	ids := r.byUser[userID]
-	total := len(ids)
-	if total == 0 {
-		return []domain.Session{}, 0, nil
+	devices := r.devices[userID]
+	return len(devices) == 0 || devices[fingerprint], nil
+}
+
+func (r *memSessionRepo) ListByUser(_ context.Context, userID string, f domain.SessionFilter, page, limit int) ([]domain.Session, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	now := r.now()
+	all := make([]domain.Session, 0, len(r.byUser[userID]))
+	for _, id := range r.byUser[userID] {
+		s := r.sessions[id]
+		if f.ActiveOnly && (!s.Active(now) || (!f.ActiveSince.IsZero() && !s.LastActive.After(f.ActiveSince))) {
+			continue
+		}
+		all = append(all, *s)
 	}
+	// тот же порядок, что и в pg: сначала новые
+	sort.SliceStable(all, func(i, j int) bool {
+		if f.Sort == domain.SortByLastActive && !all[i].LastActive.Equal(all[j].LastActive) {
+			return all[i].LastActive.After(all[j].LastActive)
+		}
+		return all[i].CreatedAt.After(all[j].CreatedAt)
+	})
+
+	total := len(all)
 	start := (page - 1) * limit
 	if start >= total {
 		return []domain.Session{}, total, nil
@@ -184,32 +359,29 @@ func (r *memSessionRepo) ListByUser(userID string, page, limit int) ([]domain.Se
 	if end > total {
 		end = total
 	}
-	out := make([]domain.Session, 0, end-start)
-	for _, id := range ids[start:end] {
-		s := r.sessions[id]
-		out = append(out, *s)
-	}
-	return out, total, nil
+	return all[start:end], total, nil
 }
 
-func (r *memSessionRepo) Revoke(sessionID, userID string) error {
+func (r *memSessionRepo) Revoke(_ context.Context, sessionID, userID string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	s, ok := r.sessions[sessionID]
 	if !ok || s.UserID != userID {
-		return errors.New("not_found")
+		return apperrors.ErrNotFound
+	}
+	if s.RevokedAt == nil {
+		now := r.now()
+		s.RevokedAt = &now
 	}
-	now := time.Now().UTC()
-	s.RevokedAt = &now
 	return nil
 }
 
-func (r *memSessionRepo) RevokeOthers(currentSessionID, userID string) (int, error) {
+func (r *memSessionRepo) RevokeOthers(_ context.Context, currentSessionID, userID string) (int, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	ids := r.byUser[userID]
 	count := 0
-	now := time.Now().UTC()
+	now := r.now()
 	for _, id := range ids {
 		if id == currentSessionID {
 			continue
@@ -224,23 +396,24 @@ func (r *memSessionRepo) RevokeOthers(currentSessionID, userID string) (int, err
 	return count, nil
 }
 
-func (r *memSessionRepo) RevokeCurrent(sessionID, userID string) error {
-	return r.Revoke(sessionID, userID)
+func (r *memSessionRepo) RevokeCurrent(ctx context.Context, sessionID, userID string) error {
+	return r.Revoke(ctx, sessionID, userID)
 }
 
 type memCodeRepo struct {
+	memEnv
 	mu       sync.RWMutex
 	codes    []domain.VerificationCode
 	lastSent map[string]time.Time // key: userID+"|"+kind
 	cooldown time.Duration
 }
 
-func (r *memSessionRepo) RevokeAll(userID string) (int, error) {
+func (r *memSessionRepo) RevokeAll(_ context.Context, userID string) (int, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	ids := r.byUser[userID]
 	count := 0
-	now := time.Now().UTC()
+	now := r.now()
 	for _, id := range ids {
 		if s, ok := r.sessions[id]; ok && s.RevokedAt == nil {
 			s.RevokedAt = &now
@@ -250,30 +423,46 @@ func (r *memSessionRepo) RevokeAll(userID string) (int, error) {
 	return count, nil
 }
 
-func NewMemCodeRepo() domain.CodeRepo {
+func (r *memSessionRepo) deleteByUser(userID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range r.byUser[userID] {
+		delete(r.sessions, id)
+	}
+	delete(r.byUser, userID)
+	delete(r.devices, userID)
+}
+
+func NewMemCodeRepo() domain.CodeRepo { return newMemCodeRepo(systemEnv) }
+
+func newMemCodeRepo(env memEnv) *memCodeRepo {
 	return &memCodeRepo{
+		memEnv:   env,
 		codes:    []domain.VerificationCode{},
 		lastSent: map[string]time.Time{},
 		cooldown: 60 * time.Second, // простой лимит
 	}
 }
 
-func (r *memCodeRepo) Save(c domain.VerificationCode) error {
+func (r *memCodeRepo) Save(_ context.Context, c domain.VerificationCode) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	if c.ID == "" {
-		c.ID = uuid.New().String()
+		c.ID = r.newID()
+	}
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = r.now()
 	}
 	r.codes = append(r.codes, c)
 	key := c.UserID + "|" + string(c.Kind)
-	r.lastSent[key] = time.Now().UTC()
+	r.lastSent[key] = r.now()
 	return nil
 }
 
-func (r *memCodeRepo) Consume(userID string, kind domain.CodeKind, code string) (*domain.VerificationCode, error) {
+func (r *memCodeRepo) Consume(_ context.Context, userID string, kind domain.CodeKind, code string) (*domain.VerificationCode, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	now := time.Now().UTC()
+	now := r.now()
 	var foundSame bool
 
 	for i := range r.codes {
@@ -282,7 +471,7 @@ func (r *memCodeRepo) Consume(userID string, kind domain.CodeKind, code string)
 			if c.Code == code {
 				foundSame = true
 				if c.ExpiresAt.Before(now) {
-					return nil, ErrCodeExpired
+					return nil, apperrors.ErrCodeExpired
 				}
 				c.ConsumedAt = &now
 				cp := *c
@@ -291,12 +480,12 @@ func (r *memCodeRepo) Consume(userID string, kind domain.CodeKind, code string)
 		}
 	}
 	if foundSame {
-		// если код совпал, но истёк — мы бы уже вернули ErrCodeExpired
+		// если код совпал, но истёк — мы бы уже вернули apperrors.ErrCodeExpired
 	}
-	return nil, ErrCodeInvalid
+	return nil, apperrors.ErrCodeInvalid
 }
 
-func (r *memCodeRepo) ResendAllowed(userID string, kind domain.CodeKind) (bool, error) {
+func (r *memCodeRepo) ResendAllowed(_ context.Context, userID string, kind domain.CodeKind) (bool, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	key := userID + "|" + string(kind)
@@ -307,28 +496,281 @@ func (r *memCodeRepo) ResendAllowed(userID string, kind domain.CodeKind) (bool,
 	return time.Since(last) >= r.cooldown, nil
 }
 
-func (r *memUserRepo) UpdatePassword(userID string, newHash string) error {
+func (r *memUserRepo) UpdatePassword(_ context.Context, userID string, newHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[userID]
+	if !ok {
+		return apperrors.ErrNotFound
+	}
+	u.PasswordHash = &newHash
+	u.PasswordResetRequired = false
+	u.UpdatedAt = r.now()
+	return nil
+}
+
+func (r *memUserRepo) UpdatePasswordHash(_ context.Context, userID string, newHash string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	u, ok := r.users[userID]
 	if !ok {
-		return errors.New("not_found")
+		return apperrors.ErrNotFound
 	}
 	u.PasswordHash = &newHash
-	u.UpdatedAt = time.Now().UTC()
+	u.UpdatedAt = r.now()
+	return nil
+}
+
+func (r *memUserRepo) RequirePasswordReset(_ context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[userID]
+	if !ok {
+		return apperrors.ErrNotFound
+	}
+	u.PasswordResetRequired = true
+	u.UpdatedAt = r.now()
 	return nil
 }
 
 // ... в том же файле, где memUserRepo
-func (r *memUserRepo) SetTwoFA(userID string, enabled bool) error {
+func (r *memUserRepo) SetTwoFA(_ context.Context, userID string, enabled bool) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	u, ok := r.users[userID]
 	if !ok {
-		return errors.New("not_found")
+		return apperrors.ErrNotFound
 	}
 	u.TwoFAEnabled = enabled
-	u.UpdatedAt = time.Now().UTC()
+	u.UpdatedAt = r.now()
+	return nil
+}
+
+func (r *memUserRepo) SetLocale(_ context.Context, userID, locale string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[userID]
+	if !ok {
+		return apperrors.ErrNotFound
+	}
+	u.Locale = locale
+	u.UpdatedAt = r.now()
+	return nil
+}
+
+func (r *memUserRepo) LinkProvider(_ context.Context, userID, provider string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[userID]
+	if !ok {
+		return apperrors.ErrNotFound
+	}
+	if !slices.Contains(u.Providers, provider) {
+		u.Providers = append(u.Providers, provider)
+	}
+	return nil
+}
+
+func (r *memUserRepo) ScheduleDeletion(_ context.Context, userID string, purgeAfter time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[userID]
+	if !ok {
+		return apperrors.ErrNotFound
+	}
+	now := r.now()
+	u.DeletedAt = &now
+	u.PurgeAfter = &purgeAfter
+	u.UpdatedAt = now
 	return nil
 }
+
+func (r *memUserRepo) Restore(_ context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[userID]
+	if !ok {
+		return apperrors.ErrNotFound
+	}
+	u.DeletedAt = nil
+	u.PurgeAfter = nil
+	u.UpdatedAt = r.now()
+	return nil
+}
+
+func (r *memUserRepo) PurgeDeleted(_ context.Context, before time.Time, limit int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, u := range r.users {
+		if count >= limit {
+			break
+		}
+		if u.PurgeAfter == nil || u.PurgeAfter.After(before) {
+			continue
+		}
+		if r.audit != nil {
+			r.audit.anonymize(u.ID)
+		}
+		r.remove(u)
+		count++
+	}
+	return count, nil
+}
+
+func (r *memCodeRepo) ListByUser(_ context.Context, userID string) ([]domain.VerificationCode, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := []domain.VerificationCode{}
+	// новые сверху, как в PG
+	for i := len(r.codes) - 1; i >= 0; i-- {
+		if r.codes[i].UserID == userID {
+			out = append(out, r.codes[i])
+		}
+	}
+	return out, nil
+}
+
+func (r *memCodeRepo) DeleteExpired(_ context.Context, before time.Time, limit int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.codes[:0]
+	n := 0
+	for _, c := range r.codes {
+		if n < limit && (c.ExpiresAt.Before(before) || (c.ConsumedAt != nil && c.ConsumedAt.Before(before))) {
+			n++
+			continue
+		}
+		kept = append(kept, c)
+	}
+	r.codes = kept
+	return n, nil
+}
+
+func (r *memCodeRepo) deleteByUser(userID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.codes = slices.DeleteFunc(r.codes, func(c domain.VerificationCode) bool { return c.UserID == userID })
+	for key := range r.lastSent {
+		if strings.HasPrefix(key, userID+"|") {
+			delete(r.lastSent, key)
+		}
+	}
+}
+
+type memAuditRepo struct {
+	memEnv
+	mu     sync.RWMutex
+	events []domain.AuditEvent
+}
+
+func NewMemAuditRepo() domain.AuditRepo {
+	return &memAuditRepo{memEnv: systemEnv}
+}
+
+func (r *memAuditRepo) Record(_ context.Context, e domain.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e.ID = int64(len(r.events) + 1)
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = r.now()
+	}
+	r.events = append(r.events, e)
+	return nil
+}
+
+// anonymize отвязывает записи аудита от пользователя: сами события остаются.
+func (r *memAuditRepo) anonymize(userID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, e := range r.events {
+		if e.UserID != nil && *e.UserID == userID {
+			r.events[i].UserID, r.events[i].IPAddress, r.events[i].UserAgent, r.events[i].Payload = nil, nil, nil, nil
+		}
+	}
+}
+
+func (r *memAuditRepo) ListByUser(_ context.Context, userID string) ([]domain.AuditEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := []domain.AuditEvent{}
+	for i := len(r.events) - 1; i >= 0; i-- {
+		if e := r.events[i]; e.UserID != nil && *e.UserID == userID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (r *memAuditRepo) ListByAction(_ context.Context, action string, limit int) ([]domain.AuditEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := []domain.AuditEvent{}
+	for i := len(r.events) - 1; i >= 0 && len(out) < limit; i-- {
+		if e := r.events[i]; e.Action == action {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+type memExportRepo struct {
+	memEnv
+	mu      sync.RWMutex
+	exports map[string]domain.DataExport
+}
+
+func newMemExportRepo(env memEnv) *memExportRepo {
+	return &memExportRepo{memEnv: env, exports: map[string]domain.DataExport{}}
+}
+
+func (r *memExportRepo) Save(_ context.Context, e domain.DataExport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = r.now()
+	}
+	e.Data = slices.Clone(e.Data)
+	r.exports[e.ID] = e
+	return nil
+}
+
+func (r *memExportRepo) Get(_ context.Context, id string) (*domain.DataExport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.exports[id]
+	if !ok {
+		return nil, apperrors.ErrNotFound
+	}
+	e.Data = slices.Clone(e.Data)
+	return &e, nil
+}
+
+func (r *memExportRepo) DeleteExpired(_ context.Context, before time.Time, limit int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for id, e := range r.exports {
+		if n >= limit {
+			break
+		}
+		if e.ExpiresAt.Before(before) {
+			delete(r.exports, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *memExportRepo) deleteByUser(userID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, e := range r.exports {
+		if e.UserID == userID {
+			delete(r.exports, id)
+		}
+	}
+}
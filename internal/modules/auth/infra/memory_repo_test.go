@@ -0,0 +1,24 @@
+package infra
+
+import (
+	"testing"
+
+	"auth/internal/modules/auth/domain"
+	"auth/internal/modules/auth/infra/repotest"
+	"auth/internal/platform/clock"
+)
+
+func newMemRepos(*testing.T) domain.Repos {
+	return NewMemRepos(clock.System{}, nil)
+}
+
+func newMemUnitOfWork(t *testing.T) (domain.Repos, domain.UnitOfWork) {
+	r := newMemRepos(t)
+	return r, NewMemUnitOfWork(r)
+}
+
+func TestMemUserRepo(t *testing.T)    { repotest.UserRepo(t, newMemRepos) }
+func TestMemCodeRepo(t *testing.T)    { repotest.CodeRepo(t, newMemRepos) }
+func TestMemSessionRepo(t *testing.T) { repotest.SessionRepo(t, newMemRepos) }
+func TestMemExportRepo(t *testing.T)  { repotest.ExportRepo(t, newMemRepos) }
+func TestMemUnitOfWork(t *testing.T)  { repotest.UnitOfWork(t, newMemUnitOfWork) }
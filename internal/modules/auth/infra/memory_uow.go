@@ -0,0 +1,129 @@
+package infra
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+
+	"auth/internal/modules/auth/domain"
+)
+
+// snapshotter — in-memory репозиторий, который умеет откатиться к снимку.
+type snapshotter interface {
+	// snapshot запоминает текущее состояние и возвращает функцию его восстановления.
+	snapshot() (restore func())
+}
+
+// memUnitOfWork — domain.UnitOfWork для in-memory репозиториев: транзакции
+// выполняются по очереди, при ошибке состояние репозиториев восстанавливается
+// из снимка. Изменения, сделанные в это время вне Do, при откате теряются —
+// для разработки и тестов этого достаточно.
+type memUnitOfWork struct {
+	mu    sync.Mutex
+	repos domain.Repos
+}
+
+func NewMemUnitOfWork(r domain.Repos) domain.UnitOfWork {
+	return &memUnitOfWork{repos: r}
+}
+
+func (u *memUnitOfWork) Do(ctx context.Context, fn func(r domain.Repos) error) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var restore []func()
+	for _, r := range []any{u.repos.Users, u.repos.Codes, u.repos.Sessions, u.repos.Audit, u.repos.Exports} {
+		if s, ok := r.(snapshotter); ok {
+			restore = append(restore, s.snapshot())
+		}
+	}
+	if err := fn(u.repos); err != nil {
+		for _, f := range restore {
+			f()
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *memUserRepo) snapshot() func() {
+	r.mu.RLock()
+	users := make(map[string]*domain.User, len(r.users))
+	for id, u := range r.users {
+		cp := *u
+		users[id] = &cp
+	}
+	byEmail := maps.Clone(r.byEmail)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		r.users, r.byEmail = users, byEmail
+		r.mu.Unlock()
+	}
+}
+
+func (r *memSessionRepo) snapshot() func() {
+	r.mu.RLock()
+	sessions := make(map[string]*domain.Session, len(r.sessions))
+	for id, s := range r.sessions {
+		cp := *s
+		sessions[id] = &cp
+	}
+	byUser := make(map[string][]string, len(r.byUser))
+	for uid, ids := range r.byUser {
+		byUser[uid] = slices.Clone(ids)
+	}
+	devices := make(map[string]map[string]bool, len(r.devices))
+	for uid, fps := range r.devices {
+		devices[uid] = maps.Clone(fps)
+	}
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		r.sessions, r.byUser, r.devices = sessions, byUser, devices
+		r.mu.Unlock()
+	}
+}
+
+func (r *memCodeRepo) snapshot() func() {
+	r.mu.RLock()
+	codes := slices.Clone(r.codes)
+	lastSent := maps.Clone(r.lastSent)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		r.codes, r.lastSent = codes, lastSent
+		r.mu.Unlock()
+	}
+}
+
+func (r *memAuditRepo) snapshot() func() {
+	r.mu.RLock()
+	events := slices.Clone(r.events)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		r.events = events
+		r.mu.Unlock()
+	}
+}
+
+func (r *memExportRepo) snapshot() func() {
+	r.mu.RLock()
+	exports := maps.Clone(r.exports) // Data не меняется на месте: Save и Get копируют
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		r.exports = exports
+		r.mu.Unlock()
+	}
+}
@@ -0,0 +1,52 @@
+package pg
+
+import (
+	"context"
+
+	"auth/internal/modules/auth/domain"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AuditRepo struct{ db dbtx }
+
+func NewAuditRepo(db *pgxpool.Pool) *AuditRepo { return &AuditRepo{db: db} }
+
+func (r *AuditRepo) Record(ctx context.Context, e domain.AuditEvent) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO audit_logs (user_id, action, ip_address, user_agent, payload)
+		 VALUES ($1, $2, $3, $4, $5)`,
+		e.UserID, e.Action, e.IPAddress, e.UserAgent, e.Payload,
+	)
+	return err
+}
+
+func (r *AuditRepo) ListByUser(ctx context.Context, userID string) ([]domain.AuditEvent, error) {
+	return r.list(ctx,
+		`SELECT id, user_id, action, host(ip_address), user_agent, payload, created_at
+		   FROM audit_logs WHERE user_id=$1 ORDER BY created_at DESC`, userID)
+}
+
+func (r *AuditRepo) ListByAction(ctx context.Context, action string, limit int) ([]domain.AuditEvent, error) {
+	return r.list(ctx,
+		`SELECT id, user_id, action, host(ip_address), user_agent, payload, created_at
+		   FROM audit_logs WHERE action=$1 ORDER BY created_at DESC, id DESC LIMIT $2`, action, limit)
+}
+
+func (r *AuditRepo) list(ctx context.Context, sql string, args ...any) ([]domain.AuditEvent, error) {
+	rows, err := r.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []domain.AuditEvent{}
+	for rows.Next() {
+		var e domain.AuditEvent
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Action, &e.IPAddress, &e.UserAgent, &e.Payload, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
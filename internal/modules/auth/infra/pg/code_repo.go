@@ -6,28 +6,26 @@ import (
 	"errors"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"auth/internal/modules/auth/domain"
-)
-
-var (
-	// такие же семантики, как в in-memory реализации
-	ErrCodeInvalid = errors.New("code_invalid")
-	ErrCodeExpired = errors.New("code_expired")
+	apperrors "auth/internal/platform/errors"
 )
 
 type CodeRepo struct {
-	db       *pgxpool.Pool
+	db       dbtx
 	cooldown time.Duration
 }
 
-func NewCodeRepo(db *pgxpool.Pool) *CodeRepo {
+func NewCodeRepo(db *pgxpool.Pool) *CodeRepo { return newCodeRepo(db) }
+
+func newCodeRepo(db dbtx) *CodeRepo {
 	return &CodeRepo{db: db, cooldown: 60 * time.Second}
 }
 
-func (r *CodeRepo) Save(c domain.VerificationCode) error {
-	_, err := r.db.Exec(context.Background(),
+func (r *CodeRepo) Save(ctx context.Context, c domain.VerificationCode) error {
+	_, err := r.db.Exec(ctx,
 		`INSERT INTO verification_codes (user_id, kind, code, expires_at, sent_to)
 		 VALUES ($1, $2, $3, $4, $5)`,
 		c.UserID, c.Kind, c.Code, c.ExpiresAt, c.SentTo,
@@ -35,8 +33,7 @@ func (r *CodeRepo) Save(c domain.VerificationCode) error {
 	return err
 }
 
-func (r *CodeRepo) Consume(userID string, kind domain.CodeKind, code string) (*domain.VerificationCode, error) {
-	ctx := context.Background()
+func (r *CodeRepo) Consume(ctx context.Context, userID string, kind domain.CodeKind, code string) (*domain.VerificationCode, error) {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return nil, err
@@ -55,16 +52,19 @@ FOR UPDATE
 `, userID, kind, code)
 
 	if err := row.Scan(&v.ID, &v.UserID, &v.Kind, &v.Code, &v.ExpiresAt, &v.ConsumedAt, &v.SentTo, &v.CreatedAt); err != nil {
-		// нет такого кода
-		return nil, ErrCodeInvalid
+		if errors.Is(err, pgx.ErrNoRows) {
+			// нет такого кода
+			return nil, apperrors.ErrCodeInvalid
+		}
+		return nil, err
 	}
 
 	now := time.Now().UTC()
 	if v.ConsumedAt != nil {
-		return nil, ErrCodeInvalid
+		return nil, apperrors.ErrCodeInvalid
 	}
 	if now.After(v.ExpiresAt) {
-		return nil, ErrCodeExpired
+		return nil, apperrors.ErrCodeExpired
 	}
 
 	if _, err := tx.Exec(ctx, `UPDATE verification_codes SET consumed_at=$2 WHERE id=$1`, v.ID, now); err != nil {
@@ -77,9 +77,9 @@ FOR UPDATE
 	return &v, nil
 }
 
-func (r *CodeRepo) ResendAllowed(userID string, kind domain.CodeKind) (bool, error) {
+func (r *CodeRepo) ResendAllowed(ctx context.Context, userID string, kind domain.CodeKind) (bool, error) {
 	var last time.Time
-	err := r.db.QueryRow(context.Background(),
+	err := r.db.QueryRow(ctx,
 		`SELECT COALESCE(MAX(created_at), 'epoch') FROM verification_codes WHERE user_id=$1 AND kind=$2`,
 		userID, kind,
 	).Scan(&last)
@@ -88,3 +88,35 @@ func (r *CodeRepo) ResendAllowed(userID string, kind domain.CodeKind) (bool, err
 	}
 	return time.Since(last) >= r.cooldown, nil
 }
+
+func (r *CodeRepo) ListByUser(ctx context.Context, userID string) ([]domain.VerificationCode, error) {
+	rows, err := r.db.Query(ctx, `
+SELECT id, user_id, kind, code, expires_at, consumed_at, sent_to, created_at
+FROM verification_codes
+WHERE user_id=$1
+ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []domain.VerificationCode{}
+	for rows.Next() {
+		var v domain.VerificationCode
+		if err := rows.Scan(&v.ID, &v.UserID, &v.Kind, &v.Code, &v.ExpiresAt, &v.ConsumedAt, &v.SentTo, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
+
+func (r *CodeRepo) DeleteExpired(ctx context.Context, before time.Time, limit int) (int, error) {
+	ct, err := r.db.Exec(ctx, `
+DELETE FROM verification_codes WHERE id IN (
+  SELECT id FROM verification_codes
+   WHERE expires_at < $1 OR consumed_at < $1
+   LIMIT $2
+   FOR UPDATE SKIP LOCKED)`, before, limit)
+	return int(ct.RowsAffected()), err
+}
@@ -0,0 +1,41 @@
+package pg
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	apperrors "auth/internal/platform/errors"
+)
+
+// коды ошибок PostgreSQL
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// translate приводит ошибки pgx к общим ошибкам репозиториев.
+func translate(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return apperrors.ErrNotFound
+	}
+	return err
+}
+
+// affected — результат UPDATE/DELETE одной строки: ни одной не задели — ErrNotFound.
+func affected(ct pgconn.CommandTag, err error) error {
+	if err == nil && ct.RowsAffected() == 0 {
+		return apperrors.ErrNotFound
+	}
+	return err
+}
+
+func isUniqueViolation(err error) bool { return hasCode(err, uniqueViolation) }
+
+func isForeignKeyViolation(err error) bool { return hasCode(err, foreignKeyViolation) }
+
+func hasCode(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
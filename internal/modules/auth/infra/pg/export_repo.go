@@ -0,0 +1,44 @@
+package pg
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"auth/internal/modules/auth/domain"
+)
+
+type ExportRepo struct{ db dbtx }
+
+func NewExportRepo(db *pgxpool.Pool) *ExportRepo { return &ExportRepo{db: db} }
+
+func (r *ExportRepo) Save(ctx context.Context, e domain.DataExport) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO data_exports (id, user_id, data, expires_at)
+		 VALUES ($1, $2, $3, $4)`,
+		e.ID, e.UserID, e.Data, e.ExpiresAt,
+	)
+	return err
+}
+
+func (r *ExportRepo) Get(ctx context.Context, id string) (*domain.DataExport, error) {
+	var e domain.DataExport
+	err := r.db.QueryRow(ctx,
+		`SELECT id, user_id, data, created_at, expires_at FROM data_exports WHERE id=$1`, id,
+	).Scan(&e.ID, &e.UserID, &e.Data, &e.CreatedAt, &e.ExpiresAt)
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &e, nil
+}
+
+func (r *ExportRepo) DeleteExpired(ctx context.Context, before time.Time, limit int) (int, error) {
+	ct, err := r.db.Exec(ctx, `
+DELETE FROM data_exports WHERE id IN (
+  SELECT id FROM data_exports
+   WHERE expires_at < $1
+   LIMIT $2
+   FOR UPDATE SKIP LOCKED)`, before, limit)
+	return int(ct.RowsAffected()), err
+}
@@ -0,0 +1,195 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"auth/internal/db"
+	"auth/migrations"
+)
+
+// sharedPool — пул к тестовой базе с применёнными миграциями; nil — базы нет,
+// тесты пропускаются (причина в skipReason).
+var (
+	sharedPool *pgxpool.Pool
+	skipReason string
+)
+
+// TestMain поднимает базу для тестов пакета: TEST_PG_DSN, если задан, иначе
+// временный кластер из локального бинарника postgres (PG_BIN_DIR, PATH или
+// /usr/lib/postgresql/*/bin) на unix-сокете — без сети и контейнеров.
+// Без базы тесты пропускаются, но в CI (CI или TEST_PG_REQUIRED) это ошибка.
+func TestMain(m *testing.M) {
+	os.Exit(runTests(m))
+}
+
+func runTests(m *testing.M) int {
+	dsn := os.Getenv("TEST_PG_DSN")
+	if dsn == "" {
+		local, stop, err := startLocalPostgres()
+		if err != nil {
+			if os.Getenv("CI") != "" || os.Getenv("TEST_PG_REQUIRED") != "" {
+				log.Printf("pg tests: postgres is required: %v", err)
+				return 1
+			}
+			skipReason = err.Error()
+			return m.Run()
+		}
+		defer stop()
+		dsn = local
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		log.Printf("pg tests: %v", err)
+		return 1
+	}
+	defer pool.Close()
+	mg, err := db.NewMigrator(pool, migrations.FS)
+	if err != nil {
+		log.Printf("pg tests: %v", err)
+		return 1
+	}
+	if _, err := mg.Up(ctx); err != nil {
+		log.Printf("pg tests: migrate: %v", err)
+		return 1
+	}
+	sharedPool = pool
+	return m.Run()
+}
+
+// testPool — пул к тестовой базе; таблицы очищаются перед каждым вызовом,
+// поэтому TEST_PG_DSN должен указывать на отдельную базу.
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	if sharedPool == nil {
+		t.Skip(skipReason)
+	}
+	if _, err := sharedPool.Exec(context.Background(), `TRUNCATE users, audit_logs CASCADE`); err != nil {
+		t.Fatal(err)
+	}
+	return sharedPool
+}
+
+// startLocalPostgres создаёт кластер во временном каталоге и запускает его только
+// на unix-сокете. stop останавливает сервер и удаляет каталог.
+func startLocalPostgres() (dsn string, stop func(), err error) {
+	bin, err := postgresBinDir()
+	if err != nil {
+		return "", nil, err
+	}
+	dir, err := os.MkdirTemp("", "auth-pg-test-")
+	if err != nil {
+		return "", nil, err
+	}
+	cred, err := postgresCredential(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+	data := filepath.Join(dir, "data")
+	run := func(name string, args ...string) error {
+		cmd := exec.Command(filepath.Join(bin, name), args...)
+		if cred != nil {
+			cmd.SysProcAttr = &syscall.SysProcAttr{Credential: cred}
+		}
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%s: %w\n%s", name, err, out)
+		}
+		return nil
+	}
+	if err := run("initdb", "-D", data, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-sync"); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+	port := freePort()
+	opts := fmt.Sprintf("-p %d -k %s -c listen_addresses='' -c fsync=off", port, dir)
+	if err := run("pg_ctl", "-D", data, "-l", filepath.Join(dir, "log"), "-o", opts, "-w", "-t", "30", "start"); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+	stop = func() {
+		if err := run("pg_ctl", "-D", data, "-m", "immediate", "stop"); err != nil {
+			log.Printf("pg tests: %v", err)
+		}
+		os.RemoveAll(dir)
+	}
+	return fmt.Sprintf("postgres://postgres@/postgres?host=%s&port=%d&sslmode=disable", dir, port), stop, nil
+}
+
+// postgresCredential — от чьего имени запускать initdb и pg_ctl: postgres
+// отказывается работать от root, поэтому под root берётся непривилегированный
+// пользователь (TEST_PG_USER, иначе postgres или nobody) и ему отдаётся каталог
+// кластера. nil — запускать от текущего пользователя.
+func postgresCredential(dir string) (*syscall.Credential, error) {
+	if os.Geteuid() != 0 {
+		return nil, nil
+	}
+	names := []string{"postgres", "nobody"}
+	if name := os.Getenv("TEST_PG_USER"); name != "" {
+		names = []string{name}
+	}
+	for _, name := range names {
+		u, err := user.Lookup(name)
+		if err != nil {
+			continue
+		}
+		uid, err1 := strconv.ParseUint(u.Uid, 10, 32)
+		gid, err2 := strconv.ParseUint(u.Gid, 10, 32)
+		if err1 != nil || err2 != nil || uid == 0 {
+			continue
+		}
+		if err := os.Chown(dir, int(uid), int(gid)); err != nil {
+			return nil, err
+		}
+		return &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}, nil
+	}
+	return nil, fmt.Errorf("running as root and no unprivileged user for initdb (set TEST_PG_USER)")
+}
+
+// postgresBinDir ищет каталог с initdb и pg_ctl; из /usr/lib/postgresql берётся старшая версия.
+func postgresBinDir() (string, error) {
+	if dir := os.Getenv("PG_BIN_DIR"); dir != "" {
+		return dir, nil
+	}
+	if p, err := exec.LookPath("initdb"); err == nil {
+		return filepath.Dir(p), nil
+	}
+	dirs, _ := filepath.Glob("/usr/lib/postgresql/*/bin")
+	sort.Slice(dirs, func(i, j int) bool { return pgMajor(dirs[i]) > pgMajor(dirs[j]) })
+	for _, d := range dirs {
+		if _, err := os.Stat(filepath.Join(d, "initdb")); err == nil {
+			return d, nil
+		}
+	}
+	return "", fmt.Errorf("TEST_PG_DSN is not set and no local postgres found (set PG_BIN_DIR)")
+}
+
+func pgMajor(binDir string) int {
+	n, _ := strconv.Atoi(filepath.Base(filepath.Dir(binDir)))
+	return n
+}
+
+// freePort — порт для сокета; слушать TCP сервер не будет, номер входит только в имя сокета.
+func freePort() int {
+	if l, err := net.Listen("tcp", "127.0.0.1:0"); err == nil {
+		defer l.Close()
+		return l.Addr().(*net.TCPAddr).Port
+	}
+	return 54000 + int(time.Now().UnixNano()%1000)
+}
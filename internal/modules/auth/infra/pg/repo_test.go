@@ -0,0 +1,35 @@
+package pg
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"auth/internal/modules/auth/domain"
+	"auth/internal/modules/auth/infra/repotest"
+)
+
+func newPGRepos(t *testing.T) domain.Repos {
+	return pgRepos(testPool(t))
+}
+
+func newPGUnitOfWork(t *testing.T) (domain.Repos, domain.UnitOfWork) {
+	pool := testPool(t)
+	return pgRepos(pool), NewUnitOfWork(pool)
+}
+
+func pgRepos(pool *pgxpool.Pool) domain.Repos {
+	return domain.Repos{
+		Users:    NewUserRepo(pool),
+		Codes:    NewCodeRepo(pool),
+		Sessions: NewSessionRepo(pool),
+		Audit:    NewAuditRepo(pool),
+		Exports:  NewExportRepo(pool),
+	}
+}
+
+func TestUserRepo(t *testing.T)    { repotest.UserRepo(t, newPGRepos) }
+func TestCodeRepo(t *testing.T)    { repotest.CodeRepo(t, newPGRepos) }
+func TestSessionRepo(t *testing.T) { repotest.SessionRepo(t, newPGRepos) }
+func TestExportRepo(t *testing.T)  { repotest.ExportRepo(t, newPGRepos) }
+func TestUnitOfWork(t *testing.T)  { repotest.UnitOfWork(t, newPGUnitOfWork) }
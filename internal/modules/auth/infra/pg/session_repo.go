@@ -2,39 +2,130 @@ package pg
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"auth/internal/modules/auth/domain"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-type SessionRepo struct{ db *pgxpool.Pool }
+type SessionRepo struct{ db dbtx }
 
 func NewSessionRepo(db *pgxpool.Pool) *SessionRepo { return &SessionRepo{db: db} }
 
-func (r *SessionRepo) Create(s domain.Session) (*domain.Session, error) {
-	ctx := context.Background()
-	q := `INSERT INTO sessions (user_id, refresh_token_hash, device_name, ip_address, user_agent)
-		  VALUES ($1, $2, $3, $4, $5)
-		  RETURNING id, user_id, refresh_token_hash, device_name, ip_address::text, user_agent, last_active, created_at, revoked_at`
-	row := r.db.QueryRow(ctx, q, s.UserID, s.RefreshTokenHash, s.DeviceName, s.IPAddress, s.UserAgent)
-	var out domain.Session
-	if err := row.Scan(&out.ID, &out.UserID, &out.RefreshTokenHash, &out.DeviceName, &out.IPAddress, &out.UserAgent, &out.LastActive, &out.CreatedAt, &out.RevokedAt); err != nil {
+// порядок колонок должен совпадать с scanSession; host() — адрес без маски (::text даёт "1.2.3.4/32")
+const sessionColumns = `id, user_id, refresh_token_hash, device_name, host(ip_address), user_agent,
+	last_active, created_at, revoked_at, expires_at, device_id, fingerprint, city, country, remember_me`
+
+func scanSession(row interface {
+	Scan(dest ...any) error
+}) (*domain.Session, error) {
+	var s domain.Session
+	if err := row.Scan(&s.ID, &s.UserID, &s.RefreshTokenHash, &s.DeviceName, &s.IPAddress, &s.UserAgent,
+		&s.LastActive, &s.CreatedAt, &s.RevokedAt, &s.ExpiresAt, &s.DeviceID, &s.Fingerprint,
+		&s.City, &s.Country, &s.RememberMe); err != nil {
+		return nil, translate(err)
+	}
+	return &s, nil
+}
+
+func (r *SessionRepo) Create(ctx context.Context, s domain.Session) (*domain.Session, error) {
+	return insertSession(ctx, r.db, s)
+}
+
+// CreateWithLimit считает активные сессии и создаёт новую в одной транзакции;
+// строка пользователя блокируется, чтобы параллельные входы не обошли лимит.
+// Брошенные сессии (last_active не позже limit.ActiveSince) не считаются и не вытесняются.
+func (r *SessionRepo) CreateWithLimit(ctx context.Context, s domain.Session, limit domain.SessionLimit) (*domain.Session, error) {
+	if limit.Max <= 0 {
+		return r.Create(ctx, s)
+	}
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
 		return nil, err
 	}
-	return &out, nil
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM users WHERE id=$1 FOR UPDATE`, s.UserID); err != nil {
+		return nil, err
+	}
+	var active int
+	if err := tx.QueryRow(ctx,
+		`SELECT COUNT(*) FROM sessions WHERE user_id=$1 AND revoked_at IS NULL AND expires_at > now() AND last_active > $2`,
+		s.UserID, limit.ActiveSince).Scan(&active); err != nil {
+		return nil, err
+	}
+	if over := active - limit.Max + 1; over > 0 {
+		if !limit.Evict {
+			return nil, domain.ErrSessionLimit
+		}
+		if _, err := tx.Exec(ctx, `
+UPDATE sessions SET revoked_at=now() WHERE id IN (
+  SELECT id FROM sessions
+   WHERE user_id=$1 AND revoked_at IS NULL AND expires_at > now() AND last_active > $3
+   ORDER BY last_active ASC
+   LIMIT $2)`, s.UserID, over, limit.ActiveSince); err != nil {
+			return nil, err
+		}
+	}
+
+	created, err := insertSession(ctx, tx, s)
+	if err != nil {
+		return nil, err
+	}
+	return created, tx.Commit(ctx)
+}
+
+func insertSession(ctx context.Context, q interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}, s domain.Session) (*domain.Session, error) {
+	// без явного срока — как у колонки по умолчанию и в memory-репозитории
+	var expiresAt *time.Time
+	if !s.ExpiresAt.IsZero() {
+		expiresAt = &s.ExpiresAt
+	}
+	// вместе с сессией запоминаем устройство в user_devices (см. KnownDevice)
+	sql := `WITH s AS (
+		  INSERT INTO sessions (user_id, refresh_token_hash, device_name, ip_address, user_agent, device_id, fingerprint,
+		  city, country, remember_me, expires_at)
+		  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, COALESCE($11, now() + interval '30 days'))
+		  RETURNING *
+		), d AS (
+		  INSERT INTO user_devices (user_id, fingerprint)
+		  SELECT user_id, fingerprint FROM s WHERE fingerprint IS NOT NULL
+		  ON CONFLICT (user_id, fingerprint) DO UPDATE SET last_seen = now()
+		)
+		SELECT ` + sessionColumns + ` FROM s`
+	row := q.QueryRow(ctx, sql, s.UserID, s.RefreshTokenHash, s.DeviceName, s.IPAddress, s.UserAgent,
+		s.DeviceID, s.Fingerprint, s.City, s.Country, s.RememberMe, expiresAt)
+	return scanSession(row)
 }
 
-func (r *SessionRepo) ListByUser(userID string, page, limit int) ([]domain.Session, int, error) {
-	ctx := context.Background()
+func (r *SessionRepo) ListByUser(ctx context.Context, userID string, f domain.SessionFilter, page, limit int) ([]domain.Session, int, error) {
+	where, args := `user_id=$1`, []any{userID}
+	if f.ActiveOnly {
+		where += ` AND revoked_at IS NULL AND expires_at > now()`
+		if !f.ActiveSince.IsZero() {
+			args = append(args, f.ActiveSince)
+			where += fmt.Sprintf(` AND last_active > $%d`, len(args))
+		}
+	}
+	order := `created_at DESC`
+	if f.Sort == domain.SortByLastActive {
+		order = `last_active DESC, created_at DESC`
+	}
+
 	var total int
-	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM sessions WHERE user_id=$1`, userID).Scan(&total); err != nil {
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM sessions WHERE `+where, args...).Scan(&total); err != nil {
 		return nil, 0, err
 	}
 	offset := (page - 1) * limit
-	rows, err := r.db.Query(ctx, `SELECT id, user_id, refresh_token_hash, device_name, ip_address::text, user_agent, last_active, created_at, revoked_at
-							   FROM sessions WHERE user_id=$1 ORDER BY created_at DESC LIMIT $2 OFFSET $3`,
-		userID, limit, offset)
+	n := len(args)
+	rows, err := r.db.Query(ctx, fmt.Sprintf(`SELECT `+sessionColumns+`
+							   FROM sessions WHERE `+where+` ORDER BY `+order+` LIMIT $%d OFFSET $%d`, n+1, n+2),
+		append(args, limit, offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -42,47 +133,90 @@ func (r *SessionRepo) ListByUser(userID string, page, limit int) ([]domain.Sessi
 
 	out := []domain.Session{}
 	for rows.Next() {
-		var s domain.Session
-		if err := rows.Scan(&s.ID, &s.UserID, &s.RefreshTokenHash, &s.DeviceName, &s.IPAddress, &s.UserAgent, &s.LastActive, &s.CreatedAt, &s.RevokedAt); err != nil {
+		s, err := scanSession(rows)
+		if err != nil {
 			return nil, 0, err
 		}
-		out = append(out, s)
+		out = append(out, *s)
 	}
-	return out, total, nil
+	return out, total, rows.Err()
 }
 
-func (r *SessionRepo) Revoke(sessionID, userID string) error {
-	_, err := r.db.Exec(context.Background(),
-		`UPDATE sessions SET revoked_at=now() WHERE id=$1 AND user_id=$2 AND revoked_at IS NULL`, sessionID, userID)
-	return err
+func (r *SessionRepo) Revoke(ctx context.Context, sessionID, userID string) error {
+	// уже отозванная сессия остаётся с прежним revoked_at; чужая или несуществующая — ErrNotFound
+	return affected(r.db.Exec(ctx,
+		`UPDATE sessions SET revoked_at=COALESCE(revoked_at, now()) WHERE id=$1 AND user_id=$2`, sessionID, userID))
 }
 
-func (r *SessionRepo) RevokeOthers(currentSessionID, userID string) (int, error) {
-	ct, err := r.db.Exec(context.Background(),
+func (r *SessionRepo) RevokeOthers(ctx context.Context, currentSessionID, userID string) (int, error) {
+	ct, err := r.db.Exec(ctx,
 		`UPDATE sessions SET revoked_at=now()
 		 WHERE user_id=$1 AND id<>$2 AND revoked_at IS NULL`, userID, currentSessionID)
 	return int(ct.RowsAffected()), err
 }
 
-func (r *SessionRepo) RevokeCurrent(sessionID, userID string) error {
-	return r.Revoke(sessionID, userID)
+func (r *SessionRepo) RevokeCurrent(ctx context.Context, sessionID, userID string) error {
+	return r.Revoke(ctx, sessionID, userID)
 }
 
-func (r *SessionRepo) RevokeAll(userID string) (int, error) {
-	ct, err := r.db.Exec(context.Background(),
+func (r *SessionRepo) RevokeAll(ctx context.Context, userID string) (int, error) {
+	ct, err := r.db.Exec(ctx,
 		`UPDATE sessions SET revoked_at=now() WHERE user_id=$1 AND revoked_at IS NULL`, userID)
 	return int(ct.RowsAffected()), err
 }
 
-func (r *SessionRepo) FindByRefreshHash(hash string) (*domain.Session, error) {
-	row := r.db.QueryRow(context.Background(),
-		`SELECT id, user_id, refresh_token_hash, device_name, ip_address::text, user_agent,
-				last_active, created_at, revoked_at, expires_at
-		   FROM sessions WHERE refresh_token_hash=$1`, hash)
-	var s domain.Session
-	if err := row.Scan(&s.ID, &s.UserID, &s.RefreshTokenHash, &s.DeviceName,
-		&s.IPAddress, &s.UserAgent, &s.LastActive, &s.CreatedAt, &s.RevokedAt, &s.ExpiresAt); err != nil {
-		return nil, err
+func (r *SessionRepo) FindByRefreshHash(ctx context.Context, hash string) (*domain.Session, error) {
+	row := r.db.QueryRow(ctx,
+		`SELECT `+sessionColumns+` FROM sessions WHERE refresh_token_hash=$1`, hash)
+	return scanSession(row)
+}
+
+func (r *SessionRepo) Rotate(ctx context.Context, sessionID, oldHash, newHash, ip string, expiresAt time.Time) (bool, error) {
+	ct, err := r.db.Exec(ctx, `
+UPDATE sessions SET refresh_token_hash=$3, last_active=now(), expires_at=$5,
+       ip_address=COALESCE(NULLIF($4, '')::inet, ip_address)
+ WHERE id=$1 AND refresh_token_hash=$2 AND revoked_at IS NULL`, sessionID, oldHash, newHash, ip, expiresAt)
+	if err != nil {
+		return false, err
 	}
-	return &s, nil
+	return ct.RowsAffected() == 1, nil
+}
+
+// Touch обновляет всю пачку одним запросом. last_active только растёт:
+// отметка из буфера могла отстать от Rotate.
+func (r *SessionRepo) Touch(ctx context.Context, batch []domain.SessionTouch) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	ids := make([]string, len(batch))
+	ips := make([]string, len(batch))
+	ats := make([]time.Time, len(batch))
+	for i, t := range batch {
+		ids[i], ips[i], ats[i] = t.SessionID, t.IP, t.At
+	}
+	_, err := r.db.Exec(ctx, `
+UPDATE sessions s
+   SET last_active = GREATEST(s.last_active, v.at),
+       ip_address  = COALESCE(NULLIF(v.ip, '')::inet, s.ip_address)
+  FROM unnest($1::uuid[], $2::text[], $3::timestamptz[]) AS v(id, ip, at)
+ WHERE s.id = v.id AND s.revoked_at IS NULL`, ids, ips, ats)
+	return err
+}
+
+func (r *SessionRepo) KnownDevice(ctx context.Context, userID, fingerprint string) (bool, error) {
+	var known bool
+	err := r.db.QueryRow(ctx, `
+SELECT NOT EXISTS(SELECT 1 FROM user_devices WHERE user_id=$1)
+    OR EXISTS(SELECT 1 FROM user_devices WHERE user_id=$1 AND fingerprint=$2)`, userID, fingerprint).Scan(&known)
+	return known, err
+}
+
+func (r *SessionRepo) DeleteExpired(ctx context.Context, before time.Time, limit int) (int, error) {
+	ct, err := r.db.Exec(ctx, `
+DELETE FROM sessions WHERE id IN (
+  SELECT id FROM sessions
+   WHERE expires_at < $1 OR revoked_at < $1
+   LIMIT $2
+   FOR UPDATE SKIP LOCKED)`, before, limit)
+	return int(ct.RowsAffected()), err
 }
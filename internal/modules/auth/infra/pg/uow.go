@@ -0,0 +1,38 @@
+package pg
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"auth/internal/modules/auth/domain"
+)
+
+// dbtx — общее у пула и транзакции: репозиторий работает с любым из них.
+// Begin внутри транзакции открывает savepoint, поэтому методы со своей
+// транзакцией (Consume, CreateWithLimit, PurgeDeleted) работают и в UnitOfWork.
+type dbtx interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// UnitOfWork — domain.UnitOfWork поверх транзакции pgxpool.
+type UnitOfWork struct{ db *pgxpool.Pool }
+
+func NewUnitOfWork(db *pgxpool.Pool) *UnitOfWork { return &UnitOfWork{db: db} }
+
+func (u *UnitOfWork) Do(ctx context.Context, fn func(r domain.Repos) error) error {
+	return pgx.BeginFunc(ctx, u.db, func(tx pgx.Tx) error {
+		return fn(domain.Repos{
+			Users:    &UserRepo{db: tx},
+			Codes:    newCodeRepo(tx),
+			Sessions: &SessionRepo{db: tx},
+			Audit:    &AuditRepo{db: tx},
+			Exports:  &ExportRepo{db: tx},
+		})
+	})
+}
@@ -6,14 +6,22 @@ import (
 	"time"
 
 	"auth/internal/modules/auth/domain"
+	apperrors "auth/internal/platform/errors"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-type UserRepo struct{ db *pgxpool.Pool }
+type UserRepo struct{ db dbtx }
 
 func NewUserRepo(db *pgxpool.Pool) *UserRepo { return &UserRepo{db: db} }
 
+// порядок колонок должен совпадать с scanUser
+const userColumns = `id, email, phone, first_name, last_name, role, password_hash,
+	email_confirmed, phone_confirmed, is_blocked, created_at, updated_at, deleted_at, purge_after,
+	password_reset_required, COALESCE(locale, ''), twofa_enabled,
+	ARRAY(SELECT provider FROM user_providers p WHERE p.user_id = users.id ORDER BY p.created_at, p.provider)`
+
 func scanUser(row interface {
 	Scan(dest ...any) error
 }) (*domain.User, error) {
@@ -22,8 +30,10 @@ func scanUser(row interface {
 	var pw *string
 	var created, updated time.Time
 	if err := row.Scan(&u.ID, &u.Email, &phone, &u.FirstName, &u.LastName, &u.Role,
-		&pw, &u.EmailConfirmed, &u.PhoneConfirmed, &u.IsBlocked, &created, &updated); err != nil {
-		return nil, err
+		&pw, &u.EmailConfirmed, &u.PhoneConfirmed, &u.IsBlocked, &created, &updated,
+		&u.DeletedAt, &u.PurgeAfter, &u.PasswordResetRequired, &u.Locale, &u.TwoFAEnabled,
+		&u.Providers); err != nil {
+		return nil, translate(err)
 	}
 	u.Phone = phone
 	u.PasswordHash = pw
@@ -32,28 +42,26 @@ func scanUser(row interface {
 	return &u, nil
 }
 
-func (r *UserRepo) Create(p domain.CreateUserParams) (*domain.User, error) {
-	ctx := context.Background()
+func (r *UserRepo) Create(ctx context.Context, p domain.CreateUserParams) (*domain.User, error) {
 	q := `
 INSERT INTO users (email, phone, first_name, last_name, role, password_hash)
 VALUES (LOWER($1), $2, $3, $4, $5, $6)
-RETURNING id, email, phone, first_name, last_name, role, password_hash,
-          email_confirmed, phone_confirmed, is_blocked, created_at, updated_at`
+RETURNING ` + userColumns
 	row := r.db.QueryRow(ctx, q, p.Email, p.Phone, p.FirstName, p.LastName, p.Role, p.PasswordHash)
-	return scanUser(row)
+	u, err := scanUser(row)
+	if isUniqueViolation(err) {
+		return nil, apperrors.ErrEmailTaken
+	}
+	return u, err
 }
 
-func (r *UserRepo) GetByEmail(email string) (*domain.User, error) {
-	ctx := context.Background()
-	q := `SELECT id, email, phone, first_name, last_name, role, password_hash,
-	             email_confirmed, phone_confirmed, is_blocked, created_at, updated_at
-	      FROM users WHERE email = LOWER($1)`
+func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	q := `SELECT ` + userColumns + ` FROM users WHERE email = LOWER($1)`
 	row := r.db.QueryRow(ctx, q, strings.ToLower(email))
 	return scanUser(row)
 }
 
-func (r *UserRepo) ExistsByEmail(email string) (bool, error) {
-	ctx := context.Background()
+func (r *UserRepo) ExistsByEmail(ctx context.Context, email string) (bool, error) {
 	var ok bool
 	if err := r.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE email=LOWER($1))`, email).Scan(&ok); err != nil {
 		return false, err
@@ -61,43 +69,115 @@ func (r *UserRepo) ExistsByEmail(email string) (bool, error) {
 	return ok, nil
 }
 
-func (r *UserRepo) ConfirmEmail(userID string) error {
-	_, err := r.db.Exec(context.Background(), `UPDATE users SET email_confirmed=true, updated_at=now() WHERE id=$1`, userID)
-	return err
+func (r *UserRepo) ConfirmEmail(ctx context.Context, userID string) error {
+	return affected(r.db.Exec(ctx, `UPDATE users SET email_confirmed=true, updated_at=now() WHERE id=$1`, userID))
 }
 
-func (r *UserRepo) GetByID(id string) (*domain.User, error) {
-	row := r.db.QueryRow(context.Background(), `SELECT id, email, phone, first_name, last_name, role, password_hash,
-	 email_confirmed, phone_confirmed, is_blocked, created_at, updated_at FROM users WHERE id=$1`, id)
+func (r *UserRepo) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	row := r.db.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id=$1`, id)
 	return scanUser(row)
 }
 
-func (r *UserRepo) UpdateProfile(userID string, firstName, lastName, phone *string) error {
-	ctx := context.Background()
+func (r *UserRepo) UpdateProfile(ctx context.Context, userID string, firstName, lastName, phone *string) error {
 	q := `UPDATE users SET
 	        first_name = COALESCE($2, first_name),
 	        last_name  = COALESCE($3, last_name),
 	        phone      = COALESCE($4, phone),
 	        updated_at = now()
 	      WHERE id=$1`
-	_, err := r.db.Exec(ctx, q, userID, firstName, lastName, phone)
-	return err
+	return affected(r.db.Exec(ctx, q, userID, firstName, lastName, phone))
 }
 
-func (r *UserRepo) UpdatePassword(userID string, newHash string) error {
-	_, err := r.db.Exec(context.Background(), `UPDATE users SET password_hash=$2, updated_at=now() WHERE id=$1`, userID, newHash)
-	return err
+func (r *UserRepo) UpdatePassword(ctx context.Context, userID string, newHash string) error {
+	return affected(r.db.Exec(ctx,
+		`UPDATE users SET password_hash=$2, password_reset_required=false, updated_at=now() WHERE id=$1`, userID, newHash))
+}
+
+func (r *UserRepo) UpdatePasswordHash(ctx context.Context, userID string, newHash string) error {
+	return affected(r.db.Exec(ctx,
+		`UPDATE users SET password_hash=$2, updated_at=now() WHERE id=$1`, userID, newHash))
+}
+
+func (r *UserRepo) RequirePasswordReset(ctx context.Context, userID string) error {
+	return affected(r.db.Exec(ctx,
+		`UPDATE users SET password_reset_required=true, updated_at=now() WHERE id=$1`, userID))
+}
+
+func (r *UserRepo) Delete(ctx context.Context, id string) error {
+	return affected(r.db.Exec(ctx, `DELETE FROM users WHERE id=$1`, id))
+}
+
+func (r *UserRepo) SetTwoFA(ctx context.Context, userID string, enabled bool) error {
+	return affected(r.db.Exec(ctx,
+		`UPDATE users SET twofa_enabled=$2, updated_at=now() WHERE id=$1`,
+		userID, enabled,
+	))
+}
+
+func (r *UserRepo) SetLocale(ctx context.Context, userID, locale string) error {
+	return affected(r.db.Exec(ctx,
+		`UPDATE users SET locale=NULLIF($2, ''), updated_at=now() WHERE id=$1`, userID, locale))
 }
 
-func (r *UserRepo) Delete(id string) error {
-	_, err := r.db.Exec(context.Background(), `DELETE FROM users WHERE id=$1`, id)
+// LinkProvider привязывает OAuth-провайдера; повторная привязка ничего не меняет.
+func (r *UserRepo) LinkProvider(ctx context.Context, userID, provider string) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO user_providers (user_id, provider) VALUES ($1, $2) ON CONFLICT DO NOTHING`, userID, provider)
+	if isForeignKeyViolation(err) {
+		return apperrors.ErrNotFound
+	}
 	return err
 }
 
-func (r *UserRepo) SetTwoFA(userID string, enabled bool) error {
-    _, err := r.db.Exec(context.Background(),
-        `UPDATE users SET twofa_enabled=$2, updated_at=now() WHERE id=$1`,
-        userID, enabled,
-    )
-    return err
+func (r *UserRepo) ScheduleDeletion(ctx context.Context, userID string, purgeAfter time.Time) error {
+	return affected(r.db.Exec(ctx,
+		`UPDATE users SET deleted_at=now(), purge_after=$2, updated_at=now() WHERE id=$1`,
+		userID, purgeAfter,
+	))
+}
+
+func (r *UserRepo) Restore(ctx context.Context, userID string) error {
+	return affected(r.db.Exec(ctx,
+		`UPDATE users SET deleted_at=NULL, purge_after=NULL, updated_at=now() WHERE id=$1`, userID))
+}
+
+func (r *UserRepo) PurgeDeleted(ctx context.Context, before time.Time, limit int) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+SELECT id FROM users
+WHERE purge_after <= $1
+ORDER BY purge_after
+LIMIT $2
+FOR UPDATE SKIP LOCKED`, before, limit)
+	if err != nil {
+		return 0, err
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	// записи аудита оставляем, но без привязки к человеку
+	if _, err := tx.Exec(ctx, `
+UPDATE audit_logs SET user_id=NULL, ip_address=NULL, user_agent=NULL, payload=NULL
+WHERE user_id = ANY($1::uuid[])`, ids); err != nil {
+		return 0, err
+	}
+	// sessions и verification_codes удаляются каскадно
+	ct, err := tx.Exec(ctx, `DELETE FROM users WHERE id = ANY($1::uuid[])`, ids)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return int(ct.RowsAffected()), nil
 }
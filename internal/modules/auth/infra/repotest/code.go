@@ -0,0 +1,132 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"auth/internal/modules/auth/domain"
+	apperrors "auth/internal/platform/errors"
+)
+
+// CodeRepo проверяет domain.CodeRepo; пользователи создаются через Repos.Users.
+func CodeRepo(t *testing.T, newRepos NewRepos) {
+	ctx := context.Background()
+
+	t.Run("SaveAndList", func(t *testing.T) {
+		r := newRepos(t)
+		u := createUser(t, r.Users, "codes@example.com")
+		other := createUser(t, r.Users, "other-codes@example.com")
+		exp := time.Now().Add(time.Hour).Truncate(time.Microsecond)
+		must(t, r.Codes.Save(ctx, newCode(u.ID, domain.CodeSignup, "111111", exp)))
+		must(t, r.Codes.Save(ctx, newCode(u.ID, domain.CodeReset, "222222", exp)))
+		must(t, r.Codes.Save(ctx, newCode(other.ID, domain.CodeSignup, "333333", exp)))
+
+		codes, err := r.Codes.ListByUser(ctx, u.ID)
+		must(t, err)
+		if len(codes) != 2 || codes[0].Code != "222222" || codes[1].Code != "111111" {
+			t.Fatalf("ListByUser = %+v, want the user's two codes, newest first", codes)
+		}
+		c := codes[0]
+		if c.ID == "" || c.UserID != u.ID || c.Kind != domain.CodeReset || !c.ExpiresAt.Equal(exp) ||
+			c.SentTo != "user@example.com" || c.ConsumedAt != nil || c.CreatedAt.IsZero() {
+			t.Fatalf("saved code = %+v", c)
+		}
+
+		empty, err := r.Codes.ListByUser(ctx, createUser(t, r.Users, "none@example.com").ID)
+		must(t, err)
+		if empty == nil || len(empty) != 0 {
+			t.Fatalf("ListByUser without codes = %#v, want empty non-nil slice", empty)
+		}
+	})
+
+	t.Run("Consume", func(t *testing.T) {
+		r := newRepos(t)
+		u := createUser(t, r.Users, "consume@example.com")
+		must(t, r.Codes.Save(ctx, newCode(u.ID, domain.CodeSignup, "123456", time.Now().Add(time.Hour))))
+
+		_, err := r.Codes.Consume(ctx, u.ID, domain.CodeSignup, "000000")
+		wantErr(t, "wrong code", err, apperrors.ErrCodeInvalid)
+		_, err = r.Codes.Consume(ctx, u.ID, domain.CodeReset, "123456")
+		wantErr(t, "wrong kind", err, apperrors.ErrCodeInvalid)
+
+		c, err := r.Codes.Consume(ctx, u.ID, domain.CodeSignup, "123456")
+		must(t, err)
+		if c.Code != "123456" || c.ConsumedAt == nil {
+			t.Fatalf("consumed = %+v, want ConsumedAt", c)
+		}
+		_, err = r.Codes.Consume(ctx, u.ID, domain.CodeSignup, "123456")
+		wantErr(t, "second consume", err, apperrors.ErrCodeInvalid)
+
+		codes, err := r.Codes.ListByUser(ctx, u.ID)
+		must(t, err)
+		if len(codes) != 1 || codes[0].ConsumedAt == nil {
+			t.Fatalf("ListByUser after consume = %+v", codes)
+		}
+	})
+
+	t.Run("ConsumeExpired", func(t *testing.T) {
+		r := newRepos(t)
+		u := createUser(t, r.Users, "expired-code@example.com")
+		must(t, r.Codes.Save(ctx, newCode(u.ID, domain.Code2FA, "654321", time.Now().Add(-time.Minute))))
+		_, err := r.Codes.Consume(ctx, u.ID, domain.Code2FA, "654321")
+		wantErr(t, "expired code", err, apperrors.ErrCodeExpired)
+	})
+
+	t.Run("ResendAllowed", func(t *testing.T) {
+		r := newRepos(t)
+		u := createUser(t, r.Users, "resend@example.com")
+		ok, err := r.Codes.ResendAllowed(ctx, u.ID, domain.CodeSignup)
+		must(t, err)
+		if !ok {
+			t.Fatal("ResendAllowed without codes = false")
+		}
+		must(t, r.Codes.Save(ctx, newCode(u.ID, domain.CodeSignup, "111111", time.Now().Add(time.Hour))))
+		if ok, err = r.Codes.ResendAllowed(ctx, u.ID, domain.CodeSignup); err != nil || ok {
+			t.Fatalf("ResendAllowed right after Save = %v, %v; want false (cooldown)", ok, err)
+		}
+		if ok, err = r.Codes.ResendAllowed(ctx, u.ID, domain.CodeReset); err != nil || !ok {
+			t.Fatalf("ResendAllowed for another kind = %v, %v; want true", ok, err)
+		}
+	})
+
+	t.Run("DeleteExpired", func(t *testing.T) {
+		r := newRepos(t)
+		u := createUser(t, r.Users, "prune-codes@example.com")
+		now := time.Now()
+		must(t, r.Codes.Save(ctx, newCode(u.ID, domain.CodeSignup, "000001", now.Add(-2*time.Hour))))
+		must(t, r.Codes.Save(ctx, newCode(u.ID, domain.CodeReset, "000002", now.Add(time.Hour))))
+		must(t, r.Codes.Save(ctx, newCode(u.ID, domain.Code2FA, "000003", now.Add(time.Hour))))
+		_, err := r.Codes.Consume(ctx, u.ID, domain.CodeReset, "000002")
+		must(t, err)
+
+		before := now.Add(time.Minute)
+		n, err := r.Codes.DeleteExpired(ctx, before, 1)
+		must(t, err)
+		if n != 1 {
+			t.Fatalf("DeleteExpired(limit 1) = %d, want 1", n)
+		}
+		n, err = r.Codes.DeleteExpired(ctx, before, 10)
+		must(t, err)
+		if n != 1 {
+			t.Fatalf("second DeleteExpired = %d, want 1", n)
+		}
+		codes, err := r.Codes.ListByUser(ctx, u.ID)
+		must(t, err)
+		if len(codes) != 1 || codes[0].Code != "000003" {
+			t.Fatalf("codes left = %+v, want only the live one", codes)
+		}
+	})
+}
+
+func newCode(userID string, kind domain.CodeKind, code string, expiresAt time.Time) domain.VerificationCode {
+	return domain.VerificationCode{UserID: userID, Kind: kind, Code: code, ExpiresAt: expiresAt, SentTo: "user@example.com"}
+}
+
+func wantErr(t *testing.T, op string, err, want error) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Errorf("%s: err = %v, want %v", op, err, want)
+	}
+}
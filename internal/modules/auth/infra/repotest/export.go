@@ -0,0 +1,75 @@
+package repotest
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"auth/internal/modules/auth/domain"
+)
+
+// ExportRepo проверяет domain.ExportRepo.
+func ExportRepo(t *testing.T, newRepos NewRepos) {
+	ctx := context.Background()
+
+	t.Run("SaveAndGet", func(t *testing.T) {
+		r := newRepos(t)
+		u := createUser(t, r.Users, "export@example.com")
+		exp := time.Now().Add(time.Hour).Truncate(time.Microsecond)
+		e := newExport(u.ID, exp)
+		must(t, r.Exports.Save(ctx, e))
+
+		got, err := r.Exports.Get(ctx, e.ID)
+		must(t, err)
+		if got.UserID != u.ID || !bytes.Equal(got.Data, e.Data) || got.CreatedAt.IsZero() || !got.ExpiresAt.Equal(exp) {
+			t.Fatalf("Get = %+v, want %+v", got, e)
+		}
+		_, err = r.Exports.Get(ctx, uuid.NewString())
+		wantNotFound(t, "Get unknown", err)
+	})
+
+	t.Run("DeleteExpired", func(t *testing.T) {
+		r := newRepos(t)
+		u := createUser(t, r.Users, "prune-exports@example.com")
+		now := time.Now()
+		expired1, expired2 := newExport(u.ID, now.Add(-2*time.Hour)), newExport(u.ID, now.Add(-time.Hour))
+		live := newExport(u.ID, now.Add(time.Hour))
+		for _, e := range []domain.DataExport{expired1, expired2, live} {
+			must(t, r.Exports.Save(ctx, e))
+		}
+
+		n, err := r.Exports.DeleteExpired(ctx, now, 1)
+		must(t, err)
+		if n != 1 {
+			t.Fatalf("DeleteExpired(limit 1) = %d, want 1", n)
+		}
+		n, err = r.Exports.DeleteExpired(ctx, now, 10)
+		must(t, err)
+		if n != 1 {
+			t.Fatalf("second DeleteExpired = %d, want 1", n)
+		}
+		for _, e := range []domain.DataExport{expired1, expired2} {
+			_, err := r.Exports.Get(ctx, e.ID)
+			wantNotFound(t, "Get expired", err)
+		}
+		_, err = r.Exports.Get(ctx, live.ID)
+		must(t, err)
+	})
+
+	t.Run("DeletedWithUser", func(t *testing.T) {
+		r := newRepos(t)
+		u := createUser(t, r.Users, "export-bye@example.com")
+		e := newExport(u.ID, time.Now().Add(time.Hour))
+		must(t, r.Exports.Save(ctx, e))
+		must(t, r.Users.Delete(ctx, u.ID))
+		_, err := r.Exports.Get(ctx, e.ID)
+		wantNotFound(t, "Get after user delete", err)
+	})
+}
+
+func newExport(userID string, expiresAt time.Time) domain.DataExport {
+	return domain.DataExport{ID: uuid.NewString(), UserID: userID, Data: []byte(`{"profile":{}}`), ExpiresAt: expiresAt}
+}
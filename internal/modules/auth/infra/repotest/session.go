@@ -0,0 +1,394 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"auth/internal/modules/auth/domain"
+)
+
+// SessionRepo проверяет domain.SessionRepo; пользователи создаются через Repos.Users.
+func SessionRepo(t *testing.T, newRepos NewRepos) {
+	ctx := context.Background()
+
+	t.Run("CreateAndFind", func(t *testing.T) {
+		r := newRepos(t)
+		u := createUser(t, r.Users, "sess@example.com")
+		exp := time.Now().Add(48 * time.Hour).Truncate(time.Microsecond)
+		in := newSession(u.ID, "hash-1")
+		in.ExpiresAt = exp
+		in.DeviceID = strPtr("device-1")
+		in.City, in.Country = strPtr("Moscow"), strPtr("RU")
+		in.RememberMe = true
+
+		s, err := r.Sessions.Create(ctx, in)
+		must(t, err)
+		if s.ID == "" || s.CreatedAt.IsZero() || s.LastActive.IsZero() || s.RevokedAt != nil {
+			t.Fatalf("created = %+v, want id and timestamps", s)
+		}
+		found, err := r.Sessions.FindByRefreshHash(ctx, "hash-1")
+		must(t, err)
+		for _, got := range []*domain.Session{s, found} {
+			if got.ID != s.ID || got.UserID != u.ID || got.RefreshTokenHash != "hash-1" ||
+				deref(got.DeviceName) != "Laptop" || deref(got.IPAddress) != "10.0.0.1" || deref(got.UserAgent) != "test-agent" ||
+				deref(got.Fingerprint) != "fp-1" || deref(got.DeviceID) != "device-1" ||
+				deref(got.City) != "Moscow" || deref(got.Country) != "RU" || !got.RememberMe || !got.ExpiresAt.Equal(exp) {
+				t.Fatalf("session = %+v, want fields from Create", got)
+			}
+		}
+
+		_, err = r.Sessions.FindByRefreshHash(ctx, "unknown")
+		wantNotFound(t, "FindByRefreshHash", err)
+	})
+
+	t.Run("CreateDefaultExpiry", func(t *testing.T) {
+		r := newRepos(t)
+		u := createUser(t, r.Users, "default-exp@example.com")
+		s, err := r.Sessions.Create(ctx, newSession(u.ID, "h"))
+		must(t, err)
+		if d := time.Until(s.ExpiresAt); d < 29*24*time.Hour || d > 31*24*time.Hour {
+			t.Fatalf("ExpiresAt = %v, want about 30 days ahead", s.ExpiresAt)
+		}
+	})
+
+	t.Run("CreateWithLimitReject", func(t *testing.T) {
+		r := newRepos(t)
+		u := createUser(t, r.Users, "limit@example.com")
+		limit := domain.SessionLimit{Max: 2}
+		for _, h := range []string{"a", "b"} {
+			_, err := r.Sessions.CreateWithLimit(ctx, newSession(u.ID, h), limit)
+			must(t, err)
+		}
+		_, err := r.Sessions.CreateWithLimit(ctx, newSession(u.ID, "c"), limit)
+		if !errors.Is(err, domain.ErrSessionLimit) {
+			t.Fatalf("third session: err = %v, want ErrSessionLimit", err)
+		}
+		// отозванные и истёкшие в лимит не входят
+		a, err := r.Sessions.FindByRefreshHash(ctx, "a")
+		must(t, err)
+		must(t, r.Sessions.Revoke(ctx, a.ID, u.ID))
+		_, err = r.Sessions.CreateWithLimit(ctx, newSession(u.ID, "c"), limit)
+		must(t, err)
+		// без лимита
+		_, err = r.Sessions.CreateWithLimit(ctx, newSession(u.ID, "d"), domain.SessionLimit{})
+		must(t, err)
+	})
+
+	t.Run("CreateWithLimitEvict", func(t *testing.T) {
+		r := newRepos(t)
+		u := createUser(t, r.Users, "evict@example.com")
+		limit := domain.SessionLimit{Max: 2, Evict: true}
+		first, err := r.Sessions.CreateWithLimit(ctx, newSession(u.ID, "first"), limit)
+		must(t, err)
+		second, err := r.Sessions.CreateWithLimit(ctx, newSession(u.ID, "second"), limit)
+		must(t, err)
+		// первая сессия активнее второй — вытеснить должны вторую
+		must(t, r.Sessions.Touch(ctx, []domain.SessionTouch{{SessionID: first.ID, At: time.Now().Add(time.Minute)}}))
+
+		_, err = r.Sessions.CreateWithLimit(ctx, newSession(u.ID, "third"), limit)
+		must(t, err)
+		if s := findSession(t, r.Sessions, "second"); s.ID != second.ID || s.RevokedAt == nil {
+			t.Fatalf("least recently active session was not evicted: %+v", s)
+		}
+		if s := findSession(t, r.Sessions, "first"); s.RevokedAt != nil {
+			t.Fatalf("recently active session was evicted: %+v", s)
+		}
+	})
+
+	t.Run("CreateWithLimitIdle", func(t *testing.T) {
+		r := newRepos(t)
+		u := createUser(t, r.Users, "idle@example.com")
+		fresh, err := r.Sessions.CreateWithLimit(ctx, newSession(u.ID, "fresh"), domain.SessionLimit{Max: 2})
+		must(t, err)
+		_, err = r.Sessions.CreateWithLimit(ctx, newSession(u.ID, "idle"), domain.SessionLimit{Max: 2})
+		must(t, err)
+		must(t, r.Sessions.Touch(ctx, []domain.SessionTouch{{SessionID: fresh.ID, At: time.Now().Add(time.Minute)}}))
+		since := time.Now().Add(30 * time.Second)
+
+		// брошенная сессия не занимает место в лимите
+		_, err = r.Sessions.CreateWithLimit(ctx, newSession(u.ID, "third"), domain.SessionLimit{Max: 2, ActiveSince: since})
+		must(t, err)
+		// и не вытесняется вместо активной, хотя простаивает дольше
+		_, err = r.Sessions.CreateWithLimit(ctx, newSession(u.ID, "fourth"), domain.SessionLimit{Max: 1, Evict: true, ActiveSince: since})
+		must(t, err)
+		if s := findSession(t, r.Sessions, "idle"); s.RevokedAt != nil {
+			t.Fatalf("idle session was evicted: %+v", s)
+		}
+		if s := findSession(t, r.Sessions, "fresh"); s.RevokedAt == nil {
+			t.Fatalf("active session was not evicted: %+v", s)
+		}
+	})
+
+	t.Run("ListByUser", func(t *testing.T) {
+		r := newRepos(t)
+		u := createUser(t, r.Users, "list@example.com")
+		other := createUser(t, r.Users, "other@example.com")
+		s1 := createSession(t, r.Sessions, newSession(u.ID, "l1"))
+		s2 := createSession(t, r.Sessions, newSession(u.ID, "l2"))
+		s3 := createSession(t, r.Sessions, newSession(u.ID, "l3"))
+		expired := newSession(u.ID, "l4")
+		expired.ExpiresAt = time.Now().Add(-time.Hour)
+		s4 := createSession(t, r.Sessions, expired)
+		createSession(t, r.Sessions, newSession(other.ID, "o1"))
+		must(t, r.Sessions.Revoke(ctx, s3.ID, u.ID))
+		must(t, r.Sessions.Touch(ctx, []domain.SessionTouch{{SessionID: s1.ID, At: time.Now().Add(time.Minute)}}))
+
+		all, total, err := r.Sessions.ListByUser(ctx, u.ID, domain.SessionFilter{}, 1, 10)
+		must(t, err)
+		wantIDs(t, "all, newest first", all, total, 4, s4.ID, s3.ID, s2.ID, s1.ID)
+
+		page, total, err := r.Sessions.ListByUser(ctx, u.ID, domain.SessionFilter{}, 2, 3)
+		must(t, err)
+		wantIDs(t, "page 2", page, total, 4, s1.ID)
+
+		active, total, err := r.Sessions.ListByUser(ctx, u.ID, domain.SessionFilter{ActiveOnly: true}, 1, 10)
+		must(t, err)
+		wantIDs(t, "active", active, total, 2, s2.ID, s1.ID)
+
+		byActivity, total, err := r.Sessions.ListByUser(ctx, u.ID,
+			domain.SessionFilter{ActiveOnly: true, Sort: domain.SortByLastActive}, 1, 10)
+		must(t, err)
+		wantIDs(t, "active by last_active", byActivity, total, 2, s1.ID, s2.ID)
+
+		recent, total, err := r.Sessions.ListByUser(ctx, u.ID,
+			domain.SessionFilter{ActiveOnly: true, ActiveSince: time.Now().Add(30 * time.Second)}, 1, 10)
+		must(t, err)
+		wantIDs(t, "active since", recent, total, 1, s1.ID)
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		r := newRepos(t)
+		u := createUser(t, r.Users, "revoke@example.com")
+		other := createUser(t, r.Users, "stranger@example.com")
+		s := createSession(t, r.Sessions, newSession(u.ID, "r1"))
+
+		wantNotFound(t, "Revoke foreign", r.Sessions.Revoke(ctx, s.ID, other.ID))
+		wantNotFound(t, "Revoke missing", r.Sessions.Revoke(ctx, uuid.NewString(), u.ID))
+		if got := findSession(t, r.Sessions, "r1"); got.RevokedAt != nil {
+			t.Fatal("session revoked by another user")
+		}
+
+		must(t, r.Sessions.Revoke(ctx, s.ID, u.ID))
+		revoked := findSession(t, r.Sessions, "r1")
+		if revoked.RevokedAt == nil {
+			t.Fatal("Revoke: RevokedAt not set")
+		}
+		must(t, r.Sessions.Revoke(ctx, s.ID, u.ID))
+		if again := findSession(t, r.Sessions, "r1"); !again.RevokedAt.Equal(*revoked.RevokedAt) {
+			t.Fatalf("second Revoke changed RevokedAt: %v -> %v", revoked.RevokedAt, again.RevokedAt)
+		}
+
+		cur := createSession(t, r.Sessions, newSession(u.ID, "r2"))
+		must(t, r.Sessions.RevokeCurrent(ctx, cur.ID, u.ID))
+		if findSession(t, r.Sessions, "r2").RevokedAt == nil {
+			t.Fatal("RevokeCurrent: RevokedAt not set")
+		}
+	})
+
+	t.Run("RevokeOthersAndAll", func(t *testing.T) {
+		r := newRepos(t)
+		u := createUser(t, r.Users, "others@example.com")
+		other := createUser(t, r.Users, "bystander@example.com")
+		cur := createSession(t, r.Sessions, newSession(u.ID, "cur"))
+		createSession(t, r.Sessions, newSession(u.ID, "x1"))
+		createSession(t, r.Sessions, newSession(u.ID, "x2"))
+		createSession(t, r.Sessions, newSession(other.ID, "y1"))
+
+		n, err := r.Sessions.RevokeOthers(ctx, cur.ID, u.ID)
+		must(t, err)
+		if n != 2 {
+			t.Fatalf("RevokeOthers = %d, want 2", n)
+		}
+		if findSession(t, r.Sessions, "cur").RevokedAt != nil {
+			t.Fatal("RevokeOthers revoked the current session")
+		}
+
+		n, err = r.Sessions.RevokeAll(ctx, u.ID)
+		must(t, err)
+		if n != 1 {
+			t.Fatalf("RevokeAll = %d, want 1 (others already revoked)", n)
+		}
+		if n, _ = r.Sessions.RevokeAll(ctx, u.ID); n != 0 {
+			t.Fatalf("repeated RevokeAll = %d, want 0", n)
+		}
+		if findSession(t, r.Sessions, "y1").RevokedAt != nil {
+			t.Fatal("RevokeAll touched another user's session")
+		}
+	})
+
+	t.Run("Rotate", func(t *testing.T) {
+		r := newRepos(t)
+		u := createUser(t, r.Users, "rotate@example.com")
+		s := createSession(t, r.Sessions, newSession(u.ID, "old"))
+		exp := time.Now().Add(72 * time.Hour).Truncate(time.Microsecond)
+
+		ok, err := r.Sessions.Rotate(ctx, s.ID, "old", "new", "10.0.0.2", exp)
+		must(t, err)
+		if !ok {
+			t.Fatal("Rotate with the current hash = false")
+		}
+		got := findSession(t, r.Sessions, "new")
+		if got.ID != s.ID || !got.ExpiresAt.Equal(exp) || deref(got.IPAddress) != "10.0.0.2" || got.LastActive.Before(s.LastActive) {
+			t.Fatalf("after Rotate: %+v", got)
+		}
+		_, err = r.Sessions.FindByRefreshHash(ctx, "old")
+		wantNotFound(t, "FindByRefreshHash old", err)
+
+		// повтор со старым токеном (параллельный refresh) не проходит
+		if ok, err = r.Sessions.Rotate(ctx, s.ID, "old", "other", "", exp); err != nil || ok {
+			t.Fatalf("Rotate with a stale hash = %v, %v; want false", ok, err)
+		}
+		// пустой IP оставляет прежний
+		if ok, err = r.Sessions.Rotate(ctx, s.ID, "new", "newer", "", exp); err != nil || !ok {
+			t.Fatalf("Rotate = %v, %v", ok, err)
+		}
+		if got := findSession(t, r.Sessions, "newer"); deref(got.IPAddress) != "10.0.0.2" {
+			t.Fatalf("Rotate without IP changed it to %q", deref(got.IPAddress))
+		}
+
+		must(t, r.Sessions.Revoke(ctx, s.ID, u.ID))
+		if ok, err = r.Sessions.Rotate(ctx, s.ID, "newer", "newest", "", exp); err != nil || ok {
+			t.Fatalf("Rotate of a revoked session = %v, %v; want false", ok, err)
+		}
+	})
+
+	t.Run("Touch", func(t *testing.T) {
+		r := newRepos(t)
+		u := createUser(t, r.Users, "touch@example.com")
+		s := createSession(t, r.Sessions, newSession(u.ID, "t1"))
+		revoked := createSession(t, r.Sessions, newSession(u.ID, "t2"))
+		must(t, r.Sessions.Revoke(ctx, revoked.ID, u.ID))
+		must(t, r.Sessions.Touch(ctx, nil))
+
+		later := time.Now().Add(time.Hour).Truncate(time.Microsecond)
+		must(t, r.Sessions.Touch(ctx, []domain.SessionTouch{
+			{SessionID: s.ID, IP: "10.0.0.3", At: later},
+			{SessionID: revoked.ID, IP: "10.0.0.4", At: later},
+			{SessionID: uuid.NewString(), At: later},
+		}))
+		got := findSession(t, r.Sessions, "t1")
+		if !got.LastActive.Equal(later) || deref(got.IPAddress) != "10.0.0.3" {
+			t.Fatalf("after Touch: last_active=%v ip=%q", got.LastActive, deref(got.IPAddress))
+		}
+		if got := findSession(t, r.Sessions, "t2"); got.LastActive.Equal(later) || deref(got.IPAddress) != "10.0.0.1" {
+			t.Fatalf("Touch updated a revoked session: %+v", got)
+		}
+
+		// отметка из прошлого last_active не откатывает
+		must(t, r.Sessions.Touch(ctx, []domain.SessionTouch{{SessionID: s.ID, At: later.Add(-2 * time.Hour)}}))
+		if got := findSession(t, r.Sessions, "t1"); !got.LastActive.Equal(later) {
+			t.Fatalf("stale Touch moved last_active back to %v", got.LastActive)
+		}
+	})
+
+	t.Run("KnownDevice", func(t *testing.T) {
+		r := newRepos(t)
+		u := createUser(t, r.Users, "device@example.com")
+		known, err := r.Sessions.KnownDevice(ctx, u.ID, "fp-1")
+		must(t, err)
+		if !known {
+			t.Fatal("first sign-in: device should count as known")
+		}
+		s := createSession(t, r.Sessions, newSession(u.ID, "k1"))
+		wantKnown := func(stage string) {
+			t.Helper()
+			for fp, want := range map[string]bool{"fp-1": true, "fp-2": false} {
+				known, err := r.Sessions.KnownDevice(ctx, u.ID, fp)
+				must(t, err)
+				if known != want {
+					t.Errorf("%s: KnownDevice(%q) = %v, want %v", stage, fp, known, want)
+				}
+			}
+		}
+		wantKnown("active session")
+
+		// очистка сессий не стирает историю устройств
+		must(t, r.Sessions.Revoke(ctx, s.ID, u.ID))
+		_, err = r.Sessions.DeleteExpired(ctx, time.Now().Add(time.Minute), 10)
+		must(t, err)
+		wantKnown("after prune")
+	})
+
+	t.Run("DeleteExpired", func(t *testing.T) {
+		r := newRepos(t)
+		u := createUser(t, r.Users, "cleanup@example.com")
+		old := newSession(u.ID, "expired")
+		old.ExpiresAt = time.Now().Add(-2 * time.Hour)
+		createSession(t, r.Sessions, old)
+		revoked := createSession(t, r.Sessions, newSession(u.ID, "revoked"))
+		must(t, r.Sessions.Revoke(ctx, revoked.ID, u.ID))
+		createSession(t, r.Sessions, newSession(u.ID, "alive"))
+
+		before := time.Now().Add(time.Minute)
+		n, err := r.Sessions.DeleteExpired(ctx, before, 1)
+		must(t, err)
+		if n != 1 {
+			t.Fatalf("DeleteExpired(limit 1) = %d, want 1", n)
+		}
+		n, err = r.Sessions.DeleteExpired(ctx, before, 10)
+		must(t, err)
+		if n != 1 {
+			t.Fatalf("second DeleteExpired = %d, want 1", n)
+		}
+		_, total, err := r.Sessions.ListByUser(ctx, u.ID, domain.SessionFilter{}, 1, 10)
+		must(t, err)
+		if total != 1 {
+			t.Fatalf("sessions left = %d, want 1", total)
+		}
+		findSession(t, r.Sessions, "alive")
+	})
+}
+
+func newSession(userID, refreshHash string) domain.Session {
+	return domain.Session{
+		UserID:           userID,
+		RefreshTokenHash: refreshHash,
+		DeviceName:       strPtr("Laptop"),
+		IPAddress:        strPtr("10.0.0.1"),
+		UserAgent:        strPtr("test-agent"),
+		Fingerprint:      strPtr("fp-1"),
+	}
+}
+
+func createSession(t *testing.T, r domain.SessionRepo, s domain.Session) *domain.Session {
+	t.Helper()
+	created, err := r.Create(context.Background(), s)
+	must(t, err)
+	return created
+}
+
+func findSession(t *testing.T, r domain.SessionRepo, refreshHash string) *domain.Session {
+	t.Helper()
+	s, err := r.FindByRefreshHash(context.Background(), refreshHash)
+	must(t, err)
+	return s
+}
+
+func wantIDs(t *testing.T, name string, got []domain.Session, total, wantTotal int, want ...string) {
+	t.Helper()
+	ids := make([]string, len(got))
+	for i, s := range got {
+		ids[i] = s.ID
+	}
+	if total != wantTotal || len(ids) != len(want) {
+		t.Fatalf("%s: ids=%v total=%d, want %v total=%d", name, ids, total, want, wantTotal)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("%s: ids=%v, want %v", name, ids, want)
+		}
+	}
+}
+
+func strPtr(s string) *string { return &s }
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
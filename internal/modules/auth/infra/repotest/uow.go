@@ -0,0 +1,100 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"auth/internal/modules/auth/domain"
+)
+
+// NewUnitOfWork — как NewRepos, но вместе с UnitOfWork над тем же хранилищем.
+type NewUnitOfWork func(t *testing.T) (domain.Repos, domain.UnitOfWork)
+
+// UnitOfWork проверяет domain.UnitOfWork: при ошибке fn откатываются
+// изменения во всех репозиториях, без ошибки — сохраняются.
+func UnitOfWork(t *testing.T, newUoW NewUnitOfWork) {
+	ctx := context.Background()
+	errRollback := errors.New("rollback")
+
+	// write пишет в каждый репозиторий и возвращает то, по чему записи можно найти
+	type written struct {
+		userID, sessionHash, exportID, action string
+	}
+	write := func(t *testing.T, r domain.Repos, email string) written {
+		t.Helper()
+		u := createUser(t, r.Users, email)
+		w := written{userID: u.ID, sessionHash: "uow-" + email, action: "repotest_uow"}
+		must(t, r.Codes.Save(ctx, newCode(u.ID, domain.CodeSignup, "123456", time.Now().Add(time.Hour))))
+		createSession(t, r.Sessions, newSession(u.ID, w.sessionHash))
+		must(t, r.Audit.Record(ctx, domain.AuditEvent{UserID: &u.ID, Action: w.action}))
+		e := newExport(u.ID, time.Now().Add(time.Hour))
+		must(t, r.Exports.Save(ctx, e))
+		w.exportID = e.ID
+		return w
+	}
+
+	t.Run("Rollback", func(t *testing.T) {
+		repos, uow := newUoW(t)
+		// запись до транзакции откат не трогает
+		before := write(t, repos, "before@example.com")
+
+		var w written
+		err := uow.Do(ctx, func(r domain.Repos) error {
+			w = write(t, r, "rollback@example.com")
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Fatalf("Do = %v, want %v", err, errRollback)
+		}
+
+		_, err = repos.Users.GetByID(ctx, w.userID)
+		wantNotFound(t, "Users.GetByID", err)
+		codes, err := repos.Codes.ListByUser(ctx, w.userID)
+		must(t, err)
+		if len(codes) != 0 {
+			t.Errorf("codes after rollback = %+v", codes)
+		}
+		_, err = repos.Sessions.FindByRefreshHash(ctx, w.sessionHash)
+		wantNotFound(t, "Sessions.FindByRefreshHash", err)
+		events, err := repos.Audit.ListByUser(ctx, w.userID)
+		must(t, err)
+		if len(events) != 0 {
+			t.Errorf("audit after rollback = %+v", events)
+		}
+		_, err = repos.Exports.Get(ctx, w.exportID)
+		wantNotFound(t, "Exports.Get", err)
+
+		getUser(t, repos.Users, before.userID)
+		_, err = repos.Sessions.FindByRefreshHash(ctx, before.sessionHash)
+		must(t, err)
+		_, err = repos.Exports.Get(ctx, before.exportID)
+		must(t, err)
+	})
+
+	t.Run("Commit", func(t *testing.T) {
+		repos, uow := newUoW(t)
+		var w written
+		must(t, uow.Do(ctx, func(r domain.Repos) error {
+			w = write(t, r, "commit@example.com")
+			return nil
+		}))
+
+		getUser(t, repos.Users, w.userID)
+		codes, err := repos.Codes.ListByUser(ctx, w.userID)
+		must(t, err)
+		if len(codes) != 1 {
+			t.Errorf("codes after commit = %+v, want one", codes)
+		}
+		_, err = repos.Sessions.FindByRefreshHash(ctx, w.sessionHash)
+		must(t, err)
+		events, err := repos.Audit.ListByUser(ctx, w.userID)
+		must(t, err)
+		if len(events) != 1 {
+			t.Errorf("audit after commit = %+v, want one", events)
+		}
+		_, err = repos.Exports.Get(ctx, w.exportID)
+		must(t, err)
+	})
+}
@@ -0,0 +1,318 @@
+// Package repotest — контрактные тесты репозиториев: одни и те же проверки
+// запускаются против in-memory (infra) и PostgreSQL (infra/pg) реализаций,
+// чтобы они вели себя одинаково.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"auth/internal/modules/auth/domain"
+	apperrors "auth/internal/platform/errors"
+)
+
+// NewRepos вызывается для каждого подтеста и возвращает репозитории над пустым
+// хранилищем (все поля domain.Repos должны работать с одним хранилищем).
+type NewRepos func(t *testing.T) domain.Repos
+
+// UserRepo проверяет domain.UserRepo.
+func UserRepo(t *testing.T, newRepos NewRepos) {
+	ctx := context.Background()
+	newRepo := func(t *testing.T) domain.UserRepo { return newRepos(t).Users }
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		r := newRepo(t)
+		phone := "+79990000001"
+		hash := "hash"
+		created, err := r.Create(ctx, domain.CreateUserParams{
+			Email: "Ann@Example.com", Phone: &phone, FirstName: "Ann", LastName: "Lee",
+			Role: domain.RoleGuide, PasswordHash: &hash,
+		})
+		must(t, err)
+		if created.ID == "" || created.Email != "ann@example.com" {
+			t.Fatalf("created = %+v, want id and lower-cased email", created)
+		}
+
+		byID, err := r.GetByID(ctx, created.ID)
+		must(t, err)
+		byEmail, err := r.GetByEmail(ctx, "ANN@example.com")
+		must(t, err)
+		for _, u := range []*domain.User{created, byID, byEmail} {
+			if u.ID != created.ID || u.FirstName != "Ann" || u.LastName != "Lee" || u.Role != domain.RoleGuide ||
+				u.Phone == nil || *u.Phone != phone || u.PasswordHash == nil || *u.PasswordHash != hash {
+				t.Fatalf("user = %+v, want fields from Create", u)
+			}
+			if u.EmailConfirmed || u.PhoneConfirmed || u.IsBlocked || u.TwoFAEnabled || u.PasswordResetRequired ||
+				u.DeletedAt != nil || u.PurgeAfter != nil || u.Locale != "" || len(u.Providers) != 0 {
+				t.Fatalf("user = %+v, want zero flags for a new user", u)
+			}
+			if u.CreatedAt.IsZero() || u.UpdatedAt.IsZero() {
+				t.Fatalf("user = %+v, want timestamps", u)
+			}
+		}
+	})
+
+	t.Run("EmailTaken", func(t *testing.T) {
+		r := newRepo(t)
+		createUser(t, r, "dup@example.com")
+		_, err := r.Create(ctx, domain.CreateUserParams{Email: "DUP@example.com", FirstName: "B", LastName: "B", Role: domain.RoleJournalist})
+		if !errors.Is(err, apperrors.ErrEmailTaken) {
+			t.Fatalf("Create duplicate: err = %v, want ErrEmailTaken", err)
+		}
+	})
+
+	t.Run("ExistsByEmail", func(t *testing.T) {
+		r := newRepo(t)
+		createUser(t, r, "here@example.com")
+		for email, want := range map[string]bool{"here@example.com": true, "HERE@example.com": true, "gone@example.com": false} {
+			ok, err := r.ExistsByEmail(ctx, email)
+			must(t, err)
+			if ok != want {
+				t.Errorf("ExistsByEmail(%q) = %v, want %v", email, ok, want)
+			}
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		r := newRepo(t)
+		missing := uuid.NewString()
+		_, err := r.GetByID(ctx, missing)
+		wantNotFound(t, "GetByID", err)
+		_, err = r.GetByEmail(ctx, "nobody@example.com")
+		wantNotFound(t, "GetByEmail", err)
+
+		name := "X"
+		wantNotFound(t, "ConfirmEmail", r.ConfirmEmail(ctx, missing))
+		wantNotFound(t, "UpdatePassword", r.UpdatePassword(ctx, missing, "h"))
+		wantNotFound(t, "UpdatePasswordHash", r.UpdatePasswordHash(ctx, missing, "h"))
+		wantNotFound(t, "RequirePasswordReset", r.RequirePasswordReset(ctx, missing))
+		wantNotFound(t, "UpdateProfile", r.UpdateProfile(ctx, missing, &name, nil, nil))
+		wantNotFound(t, "SetTwoFA", r.SetTwoFA(ctx, missing, true))
+		wantNotFound(t, "SetLocale", r.SetLocale(ctx, missing, "en"))
+		wantNotFound(t, "LinkProvider", r.LinkProvider(ctx, missing, "google"))
+		wantNotFound(t, "ScheduleDeletion", r.ScheduleDeletion(ctx, missing, time.Now()))
+		wantNotFound(t, "Restore", r.Restore(ctx, missing))
+		wantNotFound(t, "Delete", r.Delete(ctx, missing))
+	})
+
+	t.Run("Flags", func(t *testing.T) {
+		r := newRepo(t)
+		u := createUser(t, r, "flags@example.com")
+
+		must(t, r.ConfirmEmail(ctx, u.ID))
+		must(t, r.SetTwoFA(ctx, u.ID, true))
+		must(t, r.SetLocale(ctx, u.ID, "en"))
+		must(t, r.RequirePasswordReset(ctx, u.ID))
+		got := getUser(t, r, u.ID)
+		if !got.EmailConfirmed || !got.TwoFAEnabled || got.Locale != "en" || !got.PasswordResetRequired {
+			t.Fatalf("after setters: %+v", got)
+		}
+
+		// перехеширование меняет только хеш, требование сброса остаётся
+		must(t, r.UpdatePasswordHash(ctx, u.ID, "rehashed"))
+		got = getUser(t, r, u.ID)
+		if got.PasswordHash == nil || *got.PasswordHash != "rehashed" || !got.PasswordResetRequired {
+			t.Fatalf("after rehash: %+v", got)
+		}
+
+		// смена пароля снимает требование сброса
+		must(t, r.UpdatePassword(ctx, u.ID, "new-hash"))
+		must(t, r.SetTwoFA(ctx, u.ID, false))
+		must(t, r.SetLocale(ctx, u.ID, ""))
+		got = getUser(t, r, u.ID)
+		if got.PasswordHash == nil || *got.PasswordHash != "new-hash" || got.PasswordResetRequired ||
+			got.TwoFAEnabled || got.Locale != "" || !got.EmailConfirmed {
+			t.Fatalf("after reset: %+v", got)
+		}
+	})
+
+	t.Run("Providers", func(t *testing.T) {
+		r := newRepo(t)
+		u := createUser(t, r, "oauth@example.com")
+		must(t, r.LinkProvider(ctx, u.ID, "google"))
+		must(t, r.LinkProvider(ctx, u.ID, "github"))
+		must(t, r.LinkProvider(ctx, u.ID, "google"))
+
+		got := getUser(t, r, u.ID)
+		providers := slices.Sorted(slices.Values(got.Providers))
+		if !slices.Equal(providers, []string{"github", "google"}) {
+			t.Fatalf("Providers = %v, want github and google once", got.Providers)
+		}
+		byEmail, err := r.GetByEmail(ctx, u.Email)
+		must(t, err)
+		if len(byEmail.Providers) != 2 {
+			t.Fatalf("GetByEmail Providers = %v", byEmail.Providers)
+		}
+	})
+
+	t.Run("UpdateProfile", func(t *testing.T) {
+		r := newRepo(t)
+		u := createUser(t, r, "profile@example.com")
+		first, phone := "Maria", "+79990000002"
+		must(t, r.UpdateProfile(ctx, u.ID, &first, nil, &phone))
+
+		got := getUser(t, r, u.ID)
+		if got.FirstName != first || got.LastName != u.LastName || got.Phone == nil || *got.Phone != phone {
+			t.Fatalf("after UpdateProfile: %+v", got)
+		}
+		if got.UpdatedAt.Before(u.UpdatedAt) {
+			t.Fatalf("UpdatedAt went back: %v < %v", got.UpdatedAt, u.UpdatedAt)
+		}
+	})
+
+	t.Run("ReturnsCopies", func(t *testing.T) {
+		r := newRepo(t)
+		u := createUser(t, r, "copy@example.com")
+		u.EmailConfirmed = true
+		u.Providers = append(u.Providers, "google")
+
+		got := getUser(t, r, u.ID)
+		if got.EmailConfirmed || len(got.Providers) != 0 {
+			t.Fatalf("changes to a returned user leaked into the repo: %+v", got)
+		}
+	})
+
+	t.Run("SoftDeleteAndRestore", func(t *testing.T) {
+		r := newRepo(t)
+		u := createUser(t, r, "soft@example.com")
+		purgeAfter := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+		must(t, r.ScheduleDeletion(ctx, u.ID, purgeAfter))
+
+		got := getUser(t, r, u.ID)
+		if got.DeletedAt == nil || got.PurgeAfter == nil || !got.PurgeAfter.Equal(purgeAfter) {
+			t.Fatalf("after ScheduleDeletion: deleted_at=%v purge_after=%v", got.DeletedAt, got.PurgeAfter)
+		}
+		must(t, r.Restore(ctx, u.ID))
+		got = getUser(t, r, u.ID)
+		if got.DeletedAt != nil || got.PurgeAfter != nil {
+			t.Fatalf("after Restore: deleted_at=%v purge_after=%v", got.DeletedAt, got.PurgeAfter)
+		}
+	})
+
+	t.Run("PurgeDeleted", func(t *testing.T) {
+		r := newRepo(t)
+		now := time.Now()
+		expired1 := createUser(t, r, "expired1@example.com")
+		expired2 := createUser(t, r, "expired2@example.com")
+		grace := createUser(t, r, "grace@example.com")
+		alive := createUser(t, r, "alive@example.com")
+		must(t, r.ScheduleDeletion(ctx, expired1.ID, now.Add(-2*time.Hour)))
+		must(t, r.ScheduleDeletion(ctx, expired2.ID, now.Add(-time.Hour)))
+		must(t, r.ScheduleDeletion(ctx, grace.ID, now.Add(time.Hour)))
+
+		n, err := r.PurgeDeleted(ctx, now, 1)
+		must(t, err)
+		if n != 1 {
+			t.Fatalf("PurgeDeleted(limit 1) = %d, want 1", n)
+		}
+		n, err = r.PurgeDeleted(ctx, now, 10)
+		must(t, err)
+		if n != 1 {
+			t.Fatalf("second PurgeDeleted = %d, want 1", n)
+		}
+		for _, id := range []string{expired1.ID, expired2.ID} {
+			_, err := r.GetByID(ctx, id)
+			wantNotFound(t, "GetByID purged", err)
+		}
+		getUser(t, r, grace.ID)
+		getUser(t, r, alive.ID)
+		if ok, _ := r.ExistsByEmail(ctx, expired1.Email); ok {
+			t.Fatal("purged email is still taken")
+		}
+	})
+
+	t.Run("PurgeDeletedCascades", func(t *testing.T) {
+		repos := newRepos(t)
+		now := time.Now()
+		gone := createUser(t, repos.Users, "gone@example.com")
+		kept := createUser(t, repos.Users, "kept@example.com")
+		for _, u := range []*domain.User{gone, kept} {
+			createSession(t, repos.Sessions, newSession(u.ID, "hash-"+u.ID))
+			must(t, repos.Codes.Save(ctx, newCode(u.ID, domain.CodeSignup, "123456", now.Add(time.Hour))))
+		}
+		must(t, repos.Users.ScheduleDeletion(ctx, gone.ID, now.Add(-time.Hour)))
+		_, err := repos.Users.PurgeDeleted(ctx, now, 10)
+		must(t, err)
+
+		for _, c := range []struct {
+			user *domain.User
+			want int
+		}{{gone, 0}, {kept, 1}} {
+			_, total, err := repos.Sessions.ListByUser(ctx, c.user.ID, domain.SessionFilter{}, 1, 10)
+			must(t, err)
+			codes, err := repos.Codes.ListByUser(ctx, c.user.ID)
+			must(t, err)
+			if total != c.want || len(codes) != c.want {
+				t.Errorf("%s after purge: %d sessions, %d codes, want %d of each", c.user.Email, total, len(codes), c.want)
+			}
+		}
+	})
+
+	t.Run("PurgeDeletedAnonymizesAudit", func(t *testing.T) {
+		repos := newRepos(t)
+		now := time.Now()
+		u := createUser(t, repos.Users, "audited@example.com")
+		ip, ua := "10.0.0.9", "test-agent"
+		must(t, repos.Audit.Record(ctx, domain.AuditEvent{
+			UserID: &u.ID, Action: "repotest_purge", IPAddress: &ip, UserAgent: &ua,
+			Payload: map[string]any{"email": u.Email},
+		}))
+		must(t, repos.Users.ScheduleDeletion(ctx, u.ID, now.Add(-time.Hour)))
+		_, err := repos.Users.PurgeDeleted(ctx, now, 10)
+		must(t, err)
+
+		events, err := repos.Audit.ListByAction(ctx, "repotest_purge", 10)
+		must(t, err)
+		if len(events) != 1 {
+			t.Fatalf("audit events after purge = %d, want the event kept", len(events))
+		}
+		if e := events[0]; e.UserID != nil || e.IPAddress != nil || e.UserAgent != nil || e.Payload != nil {
+			t.Errorf("audit event not anonymized: %+v", e)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		r := newRepo(t)
+		u := createUser(t, r, "bye@example.com")
+		must(t, r.Delete(ctx, u.ID))
+		_, err := r.GetByID(ctx, u.ID)
+		wantNotFound(t, "GetByID deleted", err)
+		// email снова свободен
+		createUser(t, r, "bye@example.com")
+	})
+}
+
+func createUser(t *testing.T, r domain.UserRepo, email string) *domain.User {
+	t.Helper()
+	u, err := r.Create(context.Background(), domain.CreateUserParams{
+		Email: email, FirstName: "Test", LastName: "User", Role: domain.RoleJournalist,
+	})
+	must(t, err)
+	return u
+}
+
+func getUser(t *testing.T, r domain.UserRepo, id string) *domain.User {
+	t.Helper()
+	u, err := r.GetByID(context.Background(), id)
+	must(t, err)
+	return u
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func wantNotFound(t *testing.T, op string, err error) {
+	t.Helper()
+	if !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("%s: err = %v, want ErrNotFound", op, err)
+	}
+}
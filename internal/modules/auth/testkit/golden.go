@@ -0,0 +1,118 @@
+package testkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "перезаписать эталонные файлы testdata/*.golden")
+
+// Transcript — запись сценария: запросы, ответы и письма в порядке выполнения.
+// Сверяется с эталоном testdata/<имя теста>.golden; go test -update перезаписывает эталон.
+type Transcript struct {
+	buf   bytes.Buffer
+	clock *Clock
+}
+
+// Record включает запись сценария; по окончании теста запись сверяется с эталоном.
+func (k *Kit) Record(t *testing.T) {
+	t.Helper()
+	k.transcript = &Transcript{clock: k.Clock}
+	t.Cleanup(func() {
+		if !t.Failed() {
+			Golden(t, t.Name(), k.transcript.buf.Bytes())
+		}
+	})
+}
+
+// Step добавляет в запись заголовок шага сценария.
+func (k *Kit) Step(name string) {
+	if k.transcript != nil {
+		fmt.Fprintf(&k.transcript.buf, "### %s\n\n", name)
+	}
+}
+
+func (tr *Transcript) request(req Request, body []byte, resp *Response) {
+	fmt.Fprintf(&tr.buf, "%s /api/v1%s", req.Method, req.Path)
+	if req.Token != "" {
+		tr.buf.WriteString(" (bearer)")
+	}
+	fmt.Fprintf(&tr.buf, " @ %s\n", tr.clock.Now().Format(time.RFC3339))
+	if len(body) > 0 {
+		tr.json(body)
+	}
+	fmt.Fprintf(&tr.buf, "--> %d\n", resp.Status)
+	if len(resp.Body) > 0 {
+		tr.json(resp.Body)
+	}
+	tr.buf.WriteString("\n")
+}
+
+func (tr *Transcript) mail(m Mail) {
+	var raw bytes.Buffer
+	enc := json.NewEncoder(&raw)
+	enc.SetEscapeHTML(false) // ссылки из писем — как есть, без \u0026
+	_ = enc.Encode(m)
+	tr.buf.WriteString("MAIL\n")
+	tr.json(raw.Bytes())
+	tr.buf.WriteString("\n")
+}
+
+// json пишет JSON с отступами; не-JSON — как есть.
+func (tr *Transcript) json(raw []byte) {
+	var out bytes.Buffer
+	if err := json.Indent(&out, raw, "", "  "); err != nil {
+		out.Reset()
+		out.Write(raw)
+	}
+	tr.buf.Write(bytes.TrimRight(out.Bytes(), "\n"))
+	tr.buf.WriteString("\n")
+}
+
+// Golden сравнивает got с testdata/<name>.golden (с -update — перезаписывает файл).
+func Golden(t testing.TB, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", strings.ReplaceAll(name, "/", "_")+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the golden file (go test -update to accept):\n%s", path, diff(string(want), string(got)))
+	}
+}
+
+// diff — построчная разница в стиле unified diff без контекста; для коротких эталонов достаточно.
+func diff(want, got string) string {
+	w, g := strings.Split(want, "\n"), strings.Split(got, "\n")
+	var b strings.Builder
+	for i := 0; i < len(w) || i < len(g); i++ {
+		var wl, gl string
+		if i < len(w) {
+			wl = w[i]
+		}
+		if i < len(g) {
+			gl = g[i]
+		}
+		if wl != gl {
+			fmt.Fprintf(&b, "line %d:\n- %s\n+ %s\n", i+1, wl, gl)
+		}
+	}
+	return b.String()
+}
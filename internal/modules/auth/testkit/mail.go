@@ -0,0 +1,112 @@
+package testkit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"auth/internal/platform/notify"
+)
+
+// Виды писем в Mail.Kind.
+const (
+	MailSignupCode      = "signup_code"
+	MailResetCode       = "reset_code"
+	Mail2FACode         = "2fa_code"
+	MailPasswordChanged = "password_changed"
+	MailReauthCode      = "reauth_code"
+	MailExportReady     = "export_ready"
+	MailNewDevice       = "new_device"
+)
+
+// Mail — перехваченное письмо: только то, что важно для проверок.
+type Mail struct {
+	Kind   string    `json:"kind"`
+	To     string    `json:"to"`
+	Code   string    `json:"code,omitempty"`
+	Link   string    `json:"link,omitempty"`
+	Device string    `json:"device,omitempty"`
+	Where  string    `json:"where,omitempty"`
+	At     time.Time `json:"at,omitzero"`
+}
+
+// Outbox — notify.Sender, который складывает письма в память вместо SMTP.
+type Outbox struct {
+	mu      sync.Mutex
+	mails   []Mail
+	changed chan struct{} // закрывается и пересоздаётся при каждом новом письме
+}
+
+var _ notify.Sender = (*Outbox)(nil)
+
+func NewOutbox() *Outbox { return &Outbox{changed: make(chan struct{})} }
+
+func (o *Outbox) add(m Mail) error {
+	o.mu.Lock()
+	o.mails = append(o.mails, m)
+	close(o.changed)
+	o.changed = make(chan struct{})
+	o.mu.Unlock()
+	return nil
+}
+
+// All — все письма в порядке отправки.
+func (o *Outbox) All() []Mail {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]Mail(nil), o.mails...)
+}
+
+// Wait ждёт письмо вида kind на адрес to и забирает его из ящика. Часть писем
+// уходит асинхронно, поэтому после ответа API письмо может появиться не сразу.
+func (o *Outbox) Wait(t testing.TB, kind, to string) Mail {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		o.mu.Lock()
+		for i, m := range o.mails {
+			if m.Kind == kind && m.To == to {
+				o.mails = append(o.mails[:i], o.mails[i+1:]...)
+				o.mu.Unlock()
+				return m
+			}
+		}
+		changed := o.changed
+		o.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-timeout:
+			t.Fatalf("no %s mail to %s; outbox: %+v", kind, to, o.All())
+		}
+	}
+}
+
+func (o *Outbox) SendSignupCode(_ context.Context, to, code string) error {
+	return o.add(Mail{Kind: MailSignupCode, To: to, Code: code})
+}
+
+func (o *Outbox) SendResetCode(_ context.Context, to, code string) error {
+	return o.add(Mail{Kind: MailResetCode, To: to, Code: code})
+}
+
+func (o *Outbox) Send2FACode(_ context.Context, to, code string) error {
+	return o.add(Mail{Kind: Mail2FACode, To: to, Code: code})
+}
+
+func (o *Outbox) SendPasswordChanged(_ context.Context, to string) error {
+	return o.add(Mail{Kind: MailPasswordChanged, To: to})
+}
+
+func (o *Outbox) SendReauthCode(_ context.Context, to, code string) error {
+	return o.add(Mail{Kind: MailReauthCode, To: to, Code: code})
+}
+
+func (o *Outbox) SendExportReady(_ context.Context, to, link string, expiresAt time.Time) error {
+	return o.add(Mail{Kind: MailExportReady, To: to, Link: link, At: expiresAt})
+}
+
+func (o *Outbox) SendNewDeviceAlert(_ context.Context, to, device, where string, at time.Time, notMeLink string) error {
+	return o.add(Mail{Kind: MailNewDevice, To: to, Device: device, Where: where, At: at, Link: notMeLink})
+}
@@ -0,0 +1,26 @@
+package testkit
+
+import (
+	"encoding/binary"
+	"math/rand/v2"
+	"sync"
+)
+
+// Rand — детерминированный поток байт (ChaCha8 с сидом из seed): одинаковые
+// запросы дают одинаковые коды, токены и UUID. Безопасен для параллельного чтения.
+type Rand struct {
+	mu  sync.Mutex
+	src *rand.ChaCha8
+}
+
+func NewRand(seed uint64) *Rand {
+	var s [32]byte
+	binary.LittleEndian.PutUint64(s[:], seed)
+	return &Rand{src: rand.NewChaCha8(s)}
+}
+
+func (r *Rand) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.src.Read(p)
+}
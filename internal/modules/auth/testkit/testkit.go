@@ -0,0 +1,187 @@
+// Package testkit собирает модуль auth для сквозных тестов HTTP API:
+// in-memory репозитории, перехват писем (Outbox), часы, которые идут только
+// по команде (Clock), и детерминированный источник случайности (Rand). При одном
+// и том же сценарии ответы API совпадают байт в байт, поэтому их можно сравнивать
+// с эталонными файлами (см. Transcript).
+package testkit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"auth/internal/modules/auth/domain"
+	authhttp "auth/internal/modules/auth/http"
+	"auth/internal/modules/auth/infra"
+	plathttp "auth/internal/platform/http"
+	"auth/internal/platform/security"
+)
+
+// Start — время на часах Kit в начале каждого теста.
+var Start = time.Date(2025, time.January, 15, 9, 0, 0, 0, time.UTC)
+
+// UserAgent — User-Agent запросов Kit, если не задан явно.
+const UserAgent = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+// Kit — приложение с модулем auth и его подменёнными зависимостями.
+type Kit struct {
+	App    *fiber.App
+	Module *authhttp.Module
+	Repos  domain.Repos
+	Clock  *Clock
+	Rand   *Rand
+	Mail   *Outbox
+
+	transcript *Transcript
+}
+
+// Option донастраивает модуль до регистрации маршрутов.
+type Option func(*authhttp.Module)
+
+// New собирает Kit; фоновые задачи модуля останавливаются по окончании теста.
+func New(t testing.TB, opts ...Option) *Kit {
+	t.Helper()
+	k := &Kit{
+		Clock: NewClock(Start),
+		Rand:  NewRand(1),
+		Mail:  NewOutbox(),
+	}
+	k.Repos = infra.NewMemRepos(k.Clock, k.Rand)
+	k.Module = authhttp.NewModuleRepos(k.Repos, infra.NewMemUnitOfWork(k.Repos)).
+		WithClock(k.Clock).
+		WithRandom(security.NewRandom(k.Rand)).
+		WithMailer(k.Mail).
+		WithExport(24 * time.Hour)
+	for _, opt := range opts {
+		opt(k.Module)
+	}
+	k.App = plathttp.NewServer(plathttp.Options{AppName: "auth-test"}, k.Module)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	k.Module.Start(ctx)
+	t.Cleanup(func() {
+		cancel()
+		k.Module.Wait()
+	})
+	return k
+}
+
+// Request — запрос к API; Path — без префикса /api/v1.
+type Request struct {
+	Method string
+	Path   string
+	Body   any    // кодируется в JSON; nil — без тела
+	Token  string // access-токен для Authorization: Bearer
+	Header map[string]string
+}
+
+// Response — ответ API.
+type Response struct {
+	Status int
+	Body   []byte
+}
+
+// JSON декодирует тело ответа в объект.
+func (r *Response) JSON(t testing.TB) map[string]any {
+	t.Helper()
+	var v map[string]any
+	if err := json.Unmarshal(r.Body, &v); err != nil {
+		t.Fatalf("response body is not a JSON object: %v\n%s", err, r.Body)
+	}
+	return v
+}
+
+// String — строковое поле JSON-ответа; отсутствующее поле — ошибка теста.
+func (r *Response) String(t testing.TB, field string) string {
+	t.Helper()
+	s, ok := r.JSON(t)[field].(string)
+	if !ok {
+		t.Fatalf("response has no string field %q:\n%s", field, r.Body)
+	}
+	return s
+}
+
+// Do выполняет запрос через app.Test и, если включена запись, добавляет его в Transcript.
+func (k *Kit) Do(t testing.TB, req Request) *Response {
+	t.Helper()
+	var body io.Reader
+	var raw []byte
+	if req.Body != nil {
+		var err error
+		if raw, err = json.Marshal(req.Body); err != nil {
+			t.Fatal(err)
+		}
+		body = bytes.NewReader(raw)
+	}
+	hr := httptest.NewRequest(req.Method, "/api/v1"+req.Path, body)
+	if body != nil {
+		hr.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	hr.Header.Set(fiber.HeaderUserAgent, UserAgent)
+	if req.Token != "" {
+		hr.Header.Set(fiber.HeaderAuthorization, "Bearer "+req.Token)
+	}
+	for h, v := range req.Header {
+		hr.Header.Set(h, v)
+	}
+
+	// без таймаута: хеширование пароля на медленной машине дольше секунды по умолчанию
+	hresp, err := k.App.Test(hr, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hresp.Body.Close()
+	respBody, err := io.ReadAll(hresp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := &Response{Status: hresp.StatusCode, Body: respBody}
+	if k.transcript != nil {
+		k.transcript.request(req, raw, resp)
+	}
+	return resp
+}
+
+// Post — POST с JSON-телом без авторизации.
+func (k *Kit) Post(t testing.TB, path string, body any) *Response {
+	t.Helper()
+	return k.Do(t, Request{Method: fiber.MethodPost, Path: path, Body: body})
+}
+
+// WaitMail ждёт письмо (см. Outbox.Wait) и, если включена запись, добавляет его в Transcript.
+func (k *Kit) WaitMail(t testing.TB, kind, to string) Mail {
+	t.Helper()
+	m := k.Mail.Wait(t, kind, to)
+	if k.transcript != nil {
+		k.transcript.mail(m)
+	}
+	return m
+}
+
+// LatestCode — последний выданный пользователю код вида kind, прямо из репозитория:
+// для сценариев, где код не уходит письмом.
+func (k *Kit) LatestCode(t testing.TB, email string, kind domain.CodeKind) string {
+	t.Helper()
+	ctx := context.Background()
+	u, err := k.Repos.Users.GetByEmail(ctx, email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes, err := k.Repos.Codes.ListByUser(ctx, u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range codes { // новые первыми
+		if c.Kind == kind {
+			return c.Code
+		}
+	}
+	t.Fatalf("no %s code for %s", kind, email)
+	return ""
+}
@@ -0,0 +1,14 @@
+// Package clock — источник текущего времени. Код, от которого зависят сроки
+// кодов, сессий и токенов, берёт время отсюда, чтобы в тестах его можно было подменить.
+package clock
+
+import "time"
+
+type Clock interface {
+	Now() time.Time
+}
+
+// System — системные часы.
+type System struct{}
+
+func (System) Now() time.Time { return time.Now() }
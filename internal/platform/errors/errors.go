@@ -1 +1,112 @@
-package errors
\ No newline at end of file
+// Package errors — единая модель ошибок API: типизированная ошибка с HTTP-статусом
+// и error_code, общие для репозиториев sentinel-ошибки и центральный
+// fiber.ErrorHandler, который превращает их в ответ.
+//
+// Импортируется под именем apperrors, чтобы не путать со стандартным errors.
+package errors
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Error — ошибка, которая уходит клиенту. Сравнение через errors.Is идёт по Code,
+// поэтому ErrNotFound.WithMessageID("user_not_found") остаётся ErrNotFound.
+type Error struct {
+	Status int
+	Code   string // error_code в ответе
+	// id сообщения в каталоге i18n; пусто — сообщение по Code
+	MessageID string
+	// Ошибки по полям запроса (валидация)
+	Fields []FieldError
+	// Произвольные подробности (например, нарушенные правила пароля)
+	Details any
+
+	cause error
+}
+
+// FieldError — ошибка в конкретном поле запроса. Пустой Message заполняется
+// при ответе из каталога по id "field.<rule>".
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// New создаёт ошибку; текст для клиента берётся из каталога i18n по code.
+func New(status int, code string) *Error {
+	return &Error{Status: status, Code: code}
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Code + ": " + e.cause.Error()
+	}
+	return e.Code
+}
+
+func (e *Error) Unwrap() error { return e.cause }
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithMessageID возвращает копию с другим сообщением для клиента.
+func (e *Error) WithMessageID(id string) *Error {
+	c := *e
+	c.MessageID = id
+	return &c
+}
+
+// messageID — id сообщения в каталоге.
+func (e *Error) messageID() string {
+	if e.MessageID != "" {
+		return e.MessageID
+	}
+	return e.Code
+}
+
+// WithFields возвращает копию с ошибками по полям.
+func (e *Error) WithFields(fields ...FieldError) *Error {
+	c := *e
+	c.Fields = fields
+	return &c
+}
+
+// WithDetails возвращает копию с подробностями.
+func (e *Error) WithDetails(details any) *Error {
+	c := *e
+	c.Details = details
+	return &c
+}
+
+// Wrap возвращает копию с причиной: она попадает в лог, но не в ответ.
+func (e *Error) Wrap(cause error) *Error {
+	c := *e
+	c.cause = cause
+	return &c
+}
+
+// Общие ошибки; репозитории (pg и in-memory) возвращают именно их.
+var (
+	ErrNotFound      = New(http.StatusNotFound, "NOT_FOUND")
+	ErrEmailTaken    = New(http.StatusConflict, "EMAIL_TAKEN")
+	ErrCodeInvalid   = New(http.StatusBadRequest, "INVALID_CODE")
+	ErrCodeExpired   = New(http.StatusBadRequest, "CODE_EXPIRED")
+	ErrInvalidFields = New(http.StatusBadRequest, "INVALID_FIELDS")
+	ErrValidation    = New(http.StatusBadRequest, "VALIDATION_ERROR")
+	ErrUnauthorized  = New(http.StatusUnauthorized, "UNAUTHORIZED")
+	ErrForbidden     = New(http.StatusForbidden, "FORBIDDEN")
+	ErrTimeout       = New(http.StatusServiceUnavailable, "REQUEST_TIMEOUT")
+	ErrInternal      = New(http.StatusInternalServerError, "SERVER_ERROR")
+)
+
+// As достаёт *Error из цепочки; nil, если ошибка не типизирована.
+func As(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"auth/internal/platform/i18n"
+	"auth/internal/platform/logging"
+)
+
+// MIMEProblemJSON — тип ответа по RFC 7807.
+const MIMEProblemJSON = "application/problem+json"
+
+type HandlerOptions struct {
+	// Всегда отвечать application/problem+json; иначе — только если клиент
+	// прислал его в Accept, а по умолчанию {"error_code", "message"}
+	ProblemJSON bool
+}
+
+// body — привычный формат ошибки API.
+type body struct {
+	ErrorCode string       `json:"error_code"`
+	Message   string       `json:"message"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	Details   any          `json:"details,omitempty"`
+}
+
+// problem — RFC 7807 с расширениями error_code, fields и details.
+type problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	ErrorCode string       `json:"error_code"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	Details   any          `json:"details,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+// Handler — центральный fiber.ErrorHandler: *Error отдаётся как есть, *fiber.Error
+// (404 маршрута, 413 тела и т. п.) получает error_code по статусу, истёкший дедлайн
+// запроса — 503 REQUEST_TIMEOUT, всё остальное — 500 SERVER_ERROR без подробностей для клиента.
+func Handler(opts HandlerOptions) fiber.ErrorHandler {
+	return func(c *fiber.Ctx, err error) error {
+		e := resolve(err)
+		if e.Status >= fiber.StatusInternalServerError {
+			logging.FromFiber(c).Error("request failed", "error_code", e.Code, "err", err)
+		}
+
+		lang := i18n.Lang(c)
+		msg := i18n.T(lang, e.messageID())
+		var fields []FieldError
+		if len(e.Fields) > 0 {
+			fields = make([]FieldError, len(e.Fields))
+			for i, f := range e.Fields {
+				if f.Message == "" {
+					id := "field." + f.Rule
+					if !i18n.Has(id) {
+						id = "field.invalid"
+					}
+					f.Message = i18n.T(lang, id)
+				}
+				fields[i] = f
+			}
+		}
+
+		c.Status(e.Status)
+		if opts.ProblemJSON || c.Accepts(fiber.MIMEApplicationJSON, MIMEProblemJSON) == MIMEProblemJSON {
+			rid, _ := c.Locals("requestid").(string)
+			return c.JSON(problem{
+				Type:      "about:blank",
+				Title:     http.StatusText(e.Status),
+				Status:    e.Status,
+				Detail:    msg,
+				Instance:  c.OriginalURL(),
+				ErrorCode: e.Code,
+				Fields:    fields,
+				Details:   e.Details,
+				RequestID: rid,
+			}, MIMEProblemJSON)
+		}
+		return c.JSON(body{ErrorCode: e.Code, Message: msg, Fields: fields, Details: e.Details})
+	}
+}
+
+// Middleware сразу превращает ошибку обработчика в ответ, чтобы внешние
+// middleware (метрики, трейсинг, логи) видели итоговый статус.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return c.App().ErrorHandler(c, err)
+		}
+		return nil
+	}
+}
+
+func resolve(err error) *Error {
+	e := As(err)
+	// истёк дедлайн запроса (в том числе внутри обёрнутой SERVER_ERROR)
+	if (e == nil || e.Status >= fiber.StatusInternalServerError) && errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout.Wrap(err)
+	}
+	if e != nil {
+		return e
+	}
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		if e, ok := byStatus[fe.Code]; ok {
+			return e
+		}
+		code := strings.ToUpper(strings.ReplaceAll(http.StatusText(fe.Code), " ", "_"))
+		if code == "" {
+			code = "HTTP_ERROR"
+		}
+		// неизвестный статус: в каталоге такого кода нет, отдаём текст fiber
+		return New(fe.Code, code).WithMessageID(fe.Message)
+	}
+	return ErrInternal
+}
+
+// ошибки, которые fiber и его middleware возвращают сами
+var byStatus = map[int]*Error{
+	fiber.StatusBadRequest:            ErrInvalidFields,
+	fiber.StatusUnauthorized:          ErrUnauthorized,
+	fiber.StatusForbidden:             ErrForbidden,
+	fiber.StatusNotFound:              New(fiber.StatusNotFound, "ROUTE_NOT_FOUND"),
+	fiber.StatusMethodNotAllowed:      New(fiber.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED"),
+	fiber.StatusRequestEntityTooLarge: New(fiber.StatusRequestEntityTooLarge, "BODY_TOO_LARGE"),
+	fiber.StatusTooManyRequests:       New(fiber.StatusTooManyRequests, "TOO_MANY_REQUESTS"),
+	fiber.StatusUnsupportedMediaType:  New(fiber.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE"),
+}
@@ -0,0 +1,33 @@
+package errors
+
+import "auth/internal/platform/i18n"
+
+// Тексты общих ошибок и ошибок валидации полей (id "field.<правило validator>").
+func init() {
+	i18n.Register(i18n.Catalog{
+		"NOT_FOUND":              {i18n.RU: "Не найдено", i18n.EN: "Not found"},
+		"EMAIL_TAKEN":            {i18n.RU: "Email уже занят", i18n.EN: "Email is already taken"},
+		"INVALID_CODE":           {i18n.RU: "Некорректный код", i18n.EN: "Invalid code"},
+		"CODE_EXPIRED":           {i18n.RU: "Код истёк", i18n.EN: "Code has expired"},
+		"INVALID_FIELDS":         {i18n.RU: "Некорректные данные", i18n.EN: "Invalid request data"},
+		"VALIDATION_ERROR":       {i18n.RU: "Ошибка валидации", i18n.EN: "Validation failed"},
+		"UNAUTHORIZED":           {i18n.RU: "Требуется авторизация", i18n.EN: "Authentication required"},
+		"FORBIDDEN":              {i18n.RU: "Недостаточно прав", i18n.EN: "Access denied"},
+		"SERVER_ERROR":           {i18n.RU: "Внутренняя ошибка сервера", i18n.EN: "Internal server error"},
+		"REQUEST_TIMEOUT":        {i18n.RU: "Запрос выполнялся слишком долго, повторите позже", i18n.EN: "Request took too long, please retry later"},
+		"ROUTE_NOT_FOUND":        {i18n.RU: "Метод API не найден", i18n.EN: "API method not found"},
+		"METHOD_NOT_ALLOWED":     {i18n.RU: "Метод не поддерживается", i18n.EN: "Method not allowed"},
+		"BODY_TOO_LARGE":         {i18n.RU: "Слишком большой запрос", i18n.EN: "Request body is too large"},
+		"TOO_MANY_REQUESTS":      {i18n.RU: "Слишком много запросов", i18n.EN: "Too many requests"},
+		"UNSUPPORTED_MEDIA_TYPE": {i18n.RU: "Неподдерживаемый формат запроса", i18n.EN: "Unsupported media type"},
+
+		"field.invalid":  {i18n.RU: "Некорректное значение", i18n.EN: "Invalid value"},
+		"field.required": {i18n.RU: "Обязательное поле", i18n.EN: "This field is required"},
+		"field.email":    {i18n.RU: "Некорректный формат email", i18n.EN: "Invalid email format"},
+		"field.min":      {i18n.RU: "Слишком короткое значение", i18n.EN: "Value is too short"},
+		"field.max":      {i18n.RU: "Слишком длинное значение", i18n.EN: "Value is too long"},
+		"field.oneof":    {i18n.RU: "Недопустимое значение", i18n.EN: "Value is not allowed"},
+		"field.e164":     {i18n.RU: "Телефон должен быть в формате E.164", i18n.EN: "Phone must be in E.164 format"},
+		"field.eq":       {i18n.RU: "Недопустимое значение", i18n.EN: "Value is not allowed"},
+	})
+}
@@ -0,0 +1,88 @@
+// Package geo — определение города и страны по IP.
+package geo
+
+import (
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+type Location struct {
+	City    string
+	Country string
+}
+
+// Locator определяет местоположение по IP. ok = false, если адрес не найден
+// (локальные сети, неполная база и т.п.).
+type Locator interface {
+	Lookup(ip string) (loc Location, ok bool)
+	Close() error
+}
+
+// Open открывает базу в формате MaxMind (mmdb, например GeoLite2-City).
+// Пустой путь — геолокация выключена, возвращается Noop.
+func Open(path string) (Locator, error) {
+	if path == "" {
+		return Noop{}, nil
+	}
+	r, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &MMDB{r: r, langs: []string{"ru", "en"}}, nil
+}
+
+// Noop — заглушка, когда база не настроена.
+type Noop struct{}
+
+func (Noop) Lookup(string) (Location, bool) { return Location{}, false }
+func (Noop) Close() error                   { return nil }
+
+type MMDB struct {
+	r     *maxminddb.Reader
+	langs []string // приоритет языков для названий
+}
+
+type cityRecord struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Country struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+}
+
+func (m *MMDB) Lookup(ip string) (Location, bool) {
+	addr := net.ParseIP(ip)
+	if addr == nil || addr.IsPrivate() || addr.IsLoopback() {
+		return Location{}, false
+	}
+	var rec cityRecord
+	if err := m.r.Lookup(addr, &rec); err != nil {
+		return Location{}, false
+	}
+	loc := Location{City: m.name(rec.City.Names), Country: m.name(rec.Country.Names)}
+	return loc, loc.City != "" || loc.Country != ""
+}
+
+func (m *MMDB) Close() error { return m.r.Close() }
+
+func (m *MMDB) name(names map[string]string) string {
+	for _, l := range m.langs {
+		if n := names[l]; n != "" {
+			return n
+		}
+	}
+	return ""
+}
+
+// String — «Город, Страна» (или то, что известно).
+func (l Location) String() string {
+	switch {
+	case l.City != "" && l.Country != "":
+		return l.City + ", " + l.Country
+	case l.Country != "":
+		return l.Country
+	}
+	return l.City
+}
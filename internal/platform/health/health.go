@@ -0,0 +1,125 @@
+// Package health — проверки зависимостей для /readyz.
+package health
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Check проверяет одну зависимость; nil — зависимость доступна.
+type Check func(ctx context.Context) error
+
+// Registrar — модуль, который регистрирует проверки своих зависимостей.
+type Registrar interface {
+	RegisterHealth(r *Registry)
+}
+
+type Result struct {
+	Status    string  `json:"status"` // ok | fail
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string            `json:"status"` // ok, если все проверки прошли
+	Checks map[string]Result `json:"checks"`
+}
+
+func (r Report) OK() bool { return r.Status == "ok" }
+
+// Registry хранит проверки и выполняет их параллельно с общим таймаутом.
+type Registry struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks map[string]Check
+}
+
+func NewRegistry(timeout time.Duration) *Registry {
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	return &Registry{timeout: timeout, checks: map[string]Check{}}
+}
+
+func (r *Registry) Register(name string, c Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = c
+}
+
+func (r *Registry) Run(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	r.mu.RLock()
+	checks := make(map[string]Check, len(r.checks))
+	for name, c := range r.checks {
+		checks[name] = c
+	}
+	r.mu.RUnlock()
+
+	rep := Report{Status: "ok", Checks: make(map[string]Result, len(checks))}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, c := range checks {
+		wg.Add(1)
+		go func(name string, c Check) {
+			defer wg.Done()
+			start := time.Now()
+			err := c(ctx)
+			res := Result{Status: "ok", LatencyMS: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				res.Status, res.Error = "fail", err.Error()
+			}
+			mu.Lock()
+			rep.Checks[name] = res
+			if err != nil {
+				rep.Status = "fail"
+			}
+			mu.Unlock()
+		}(name, c)
+	}
+	wg.Wait()
+	return rep
+}
+
+// Pinger — то, что умеет Ping(ctx) (например, *pgxpool.Pool).
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+func Ping(p Pinger) Check { return p.Ping }
+
+// Redis проверяет Redis командой PING без клиентской библиотеки.
+func Redis(addr string) Check {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+		}
+		if _, err := conn.Write([]byte("PING\r\n")); err != nil {
+			return err
+		}
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(line, "+PONG") {
+			return errors.New("unexpected reply: " + strings.TrimSpace(line))
+		}
+		return nil
+	}
+}
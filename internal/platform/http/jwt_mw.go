@@ -6,16 +6,18 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
+
+	"auth/internal/platform/clock"
+	apperrors "auth/internal/platform/errors"
+	"auth/internal/platform/i18n"
 )
 
-func JWTAuth(secret []byte) fiber.Handler {
+// JWTAuth проверяет access-токен; срок действия сверяется с clk.
+func JWTAuth(secret []byte, clk clock.Clock) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		h := c.Get("Authorization")
 		if !strings.HasPrefix(h, "Bearer ") {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error_code": "UNAUTHORIZED",
-				"message":    "Требуется авторизация",
-			})
+			return apperrors.ErrUnauthorized
 		}
 		tokenStr := strings.TrimPrefix(h, "Bearer ")
 		tok, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
@@ -24,19 +26,13 @@ func JWTAuth(secret []byte) fiber.Handler {
 				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
 			}
 			return secret, nil
-		})
+		}, jwt.WithTimeFunc(clk.Now))
 		if err != nil || !tok.Valid {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error_code": "UNAUTHORIZED",
-				"message":    "Требуется авторизация",
-			})
+			return apperrors.ErrUnauthorized
 		}
 		claims, ok := tok.Claims.(jwt.MapClaims)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error_code": "UNAUTHORIZED",
-				"message":    "Требуется авторизация",
-			})
+			return apperrors.ErrUnauthorized
 		}
 		if sub, _ := claims["sub"].(string); sub != "" {
 			c.Locals("user_id", sub)
@@ -47,6 +43,12 @@ func JWTAuth(secret []byte) fiber.Handler {
 		if sid, _ := claims["sid"].(string); sid != "" {
 			c.Locals("session_id", sid)
 		}
+		// сохранённый язык пользователя важнее Accept-Language. Берётся из токена,
+		// без похода в БД, поэтому смена языка в PATCH /user применяется к
+		// остальным запросам после /refresh (не позже срока жизни access-токена)
+		if locale, _ := claims["locale"].(string); locale != "" {
+			i18n.SetLang(c, locale)
+		}
 
 		return c.Next()
 	}
@@ -1,27 +1,137 @@
 package http
 
 import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+
+	apperrors "auth/internal/platform/errors"
+	"auth/internal/platform/health"
+	"auth/internal/platform/i18n"
+	"auth/internal/platform/logging"
+	"auth/internal/platform/metrics"
+	"auth/internal/platform/tracing"
 )
 
 type Options struct {
 	AppName string
+
+	// Таймауты соединения; 0 — значения по умолчанию ниже
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// Максимальный размер тела запроса в байтах; 0 — значение по умолчанию ниже
+	BodyLimit int
+	// Дедлайн контекста запроса (c.UserContext()) для работы с БД и т. п.;
+	// 0 — значение по умолчанию ниже
+	RequestTimeout time.Duration
+	// Ошибки всегда в формате problem+json
+	ProblemJSON bool
+	// Шлюзы (IP или CIDR), от которых принимаем X-Forwarded-For: c.IP() вернёт
+	// адрес клиента из заголовка. Пусто — c.IP() всегда адрес соединения.
+	TrustedProxies []string
+
+	// Проверки зависимостей для /readyz; модули, реализующие health.Registrar,
+	// добавляют в него свои. nil — /readyz проверяет только модули.
+	Health *health.Registry
 }
 
+const (
+	defaultReadTimeout  = 10 * time.Second
+	defaultWriteTimeout = 30 * time.Second // с запасом на скачивание выгрузки
+	defaultIdleTimeout  = 60 * time.Second
+	defaultBodyLimit    = 1 << 20 // API принимает только небольшие JSON
+	defaultReqTimeout   = 10 * time.Second
+)
+
 func NewServer(opts Options, modules ...Module) *fiber.App {
-	app := fiber.New(fiber.Config{AppName: opts.AppName})
+	app := fiber.New(fiber.Config{
+		AppName:      opts.AppName,
+		ReadTimeout:  orDefault(opts.ReadTimeout, defaultReadTimeout),
+		WriteTimeout: orDefault(opts.WriteTimeout, defaultWriteTimeout),
+		IdleTimeout:  orDefault(opts.IdleTimeout, defaultIdleTimeout),
+		BodyLimit:    orDefault(opts.BodyLimit, defaultBodyLimit),
+		ErrorHandler: apperrors.Handler(apperrors.HandlerOptions{ProblemJSON: opts.ProblemJSON}),
 
-	// глобальные middleware можно добавить здесь (recover, compress, requestID и т.п.)
+		// заголовок учитывается только от доверенных шлюзов, иначе клиент подставит любой IP;
+		// из списка "client, proxy1, ..." берётся первый корректный адрес
+		ProxyHeader:             fiber.HeaderXForwardedFor,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          opts.TrustedProxies,
+		EnableIPValidation:      true,
+	})
+
+	// паника в обработчике превращается в 500, а не роняет процесс;
+	// X-Request-ID берётся из запроса или генерируется и возвращается в ответе;
+	// у контекста запроса есть дедлайн, по нему отменяются запросы к БД;
+	// язык ответа выбирается по Accept-Language (после JWTAuth — по профилю);
+	// ошибки обработчиков превращаются в ответ до метрик, трейсинга и логов
+	app.Use(requestid.New())
+	app.Use(tracing.Middleware())
+	app.Use(metrics.Middleware())
+	app.Use(logging.Middleware())
+	app.Use(requestTimeout(orDefault(opts.RequestTimeout, defaultReqTimeout)))
+	app.Use(i18n.Middleware())
+	app.Use(apperrors.Middleware())
+	app.Use(recover.New(recover.Config{
+		EnableStackTrace: true,
+		StackTraceHandler: func(c *fiber.Ctx, e any) {
+			logging.FromFiber(c).Error("panic", "panic", fmt.Sprint(e), "stack", string(debug.Stack()))
+		},
+	}))
 
 	api := app.Group("/api")
 	v1 := api.Group("/v1")
 
+	checks := opts.Health
+	if checks == nil {
+		checks = health.NewRegistry(0)
+	}
+
 	// регистрация модулей
 	for _, m := range modules {
 		m.Register(v1)
+		if hr, ok := m.(health.Registrar); ok {
+			hr.RegisterHealth(checks)
+		}
 	}
 
-	// health
-	app.Get("/healthz", func(c *fiber.Ctx) error { return c.JSON(fiber.Map{"status": "ok"}) })
+	// health: livez — процесс жив, readyz — зависимости доступны и можно слать трафик
+	live := func(c *fiber.Ctx) error { return c.JSON(fiber.Map{"status": "ok"}) }
+	app.Get("/livez", live)
+	app.Get("/healthz", live) // старый адрес, оставлен для совместимости
+	app.Get("/readyz", func(c *fiber.Ctx) error {
+		rep := checks.Run(c.UserContext())
+		if !rep.OK() {
+			c.Status(fiber.StatusServiceUnavailable)
+		}
+		return c.JSON(rep)
+	})
+	// метрики Prometheus; наружу через шлюз не публикуются
+	app.Get("/metrics", metrics.Handler())
 	return app
 }
+
+// requestTimeout ограничивает c.UserContext() дедлайном; обработчик должен
+// передавать этот контекст дальше (в репозитории, исходящие вызовы).
+func requestTimeout(d time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(c.UserContext(), d)
+		defer cancel()
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
+
+func orDefault[T comparable](v, def T) T {
+	var zero T
+	if v == zero {
+		return def
+	}
+	return v
+}
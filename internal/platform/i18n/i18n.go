@@ -0,0 +1,142 @@
+// Package i18n — каталог сообщений API на нескольких языках и выбор языка
+// запроса: сохранённый язык пользователя, затем Accept-Language, затем русский.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Поддерживаемые языки
+const (
+	RU = "ru"
+	EN = "en"
+
+	Default = RU
+)
+
+var supported = []string{RU, EN}
+
+// Catalog — сообщения по id (error_code или id сообщения): язык -> текст.
+type Catalog map[string]map[string]string
+
+var (
+	mu       sync.RWMutex
+	messages = Catalog{}
+)
+
+// Register добавляет сообщения в общий каталог; модули вызывают его из init.
+func Register(c Catalog) {
+	mu.Lock()
+	defer mu.Unlock()
+	for id, texts := range c {
+		messages[id] = texts
+	}
+}
+
+// Has — есть ли сообщение с таким id в каталоге.
+func Has(id string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := messages[id]
+	return ok
+}
+
+// T возвращает текст сообщения на языке lang. Если перевода нет — текст на
+// языке по умолчанию, если нет и его — сам id. args подставляются через fmt.
+func T(lang, id string, args ...any) string {
+	mu.RLock()
+	texts := messages[id]
+	mu.RUnlock()
+
+	s, ok := texts[lang]
+	if !ok {
+		if s, ok = texts[Default]; !ok {
+			s = id
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(s, args...)
+	}
+	return s
+}
+
+// Supported — язык поддерживается ("en-US" приводится к "en").
+func Supported(lang string) (string, bool) {
+	base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(lang)), "-")
+	for _, s := range supported {
+		if base == s {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// Negotiate выбирает язык по заголовку Accept-Language с учётом q.
+func Negotiate(acceptLanguage string) string {
+	type pref struct {
+		lang string
+		q    float64
+	}
+	var prefs []pref
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		lang, ok := Supported(tag)
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if v, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			prefs = append(prefs, pref{lang, q})
+		}
+	}
+	if len(prefs) == 0 {
+		return Default
+	}
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].q > prefs[j].q })
+	return prefs[0].lang
+}
+
+// Язык запроса лежит в c.Locals: middleware кладёт выбранный по Accept-Language,
+// JWTAuth заменяет его сохранённым языком пользователя из токена.
+const localsKey = "locale"
+
+// Middleware выбирает язык по Accept-Language и отдаёт Content-Language.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(localsKey, Negotiate(c.Get(fiber.HeaderAcceptLanguage)))
+		err := c.Next()
+		c.Set(fiber.HeaderContentLanguage, Lang(c))
+		return err
+	}
+}
+
+// SetLang задаёт язык запроса, если он поддерживается.
+func SetLang(c *fiber.Ctx, lang string) {
+	if l, ok := Supported(lang); ok {
+		c.Locals(localsKey, l)
+	}
+}
+
+// Lang — язык текущего запроса.
+func Lang(c *fiber.Ctx) string {
+	if l, _ := c.Locals(localsKey).(string); l != "" {
+		return l
+	}
+	return Default
+}
+
+// Msg — сообщение на языке запроса.
+func Msg(c *fiber.Ctx, id string, args ...any) string {
+	return T(Lang(c), id, args...)
+}
@@ -0,0 +1,152 @@
+// Package logging — структурированные логи на log/slog: JSON, логгер запроса
+// с request_id/user_id/session_id и маскирование секретов.
+package logging
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const redacted = "[REDACTED]"
+
+// Ключи, значения которых никогда не попадают в лог (сравнение без учёта регистра,
+// по вхождению: "new_password", "refresh_token", "smtp_pass" тоже маскируются).
+var secretKeys = []string{"password", "pass", "secret", "token", "code", "authorization", "cookie", "sig"}
+
+// IsSecret — true, если значение по этому ключу нужно маскировать.
+func IsSecret(key string) bool {
+	k := strings.ToLower(key)
+	if k == "error_code" || k == "status_code" { // коды ответов не секретны
+		return false
+	}
+	for _, s := range secretKeys {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+	return false
+}
+
+type Options struct {
+	Level  slog.Level
+	Format string // json (по умолчанию) | text
+	Output io.Writer
+}
+
+// New создаёт логгер с маскированием секретов.
+func New(opts Options) *slog.Logger {
+	out := opts.Output
+	if out == nil {
+		out = os.Stdout
+	}
+	ho := &slog.HandlerOptions{Level: opts.Level, ReplaceAttr: redact}
+	var h slog.Handler
+	if strings.EqualFold(opts.Format, "text") {
+		h = slog.NewTextHandler(out, ho)
+	} else {
+		h = slog.NewJSONHandler(out, ho)
+	}
+	return slog.New(h)
+}
+
+// Setup делает логгер логгером по умолчанию; вызовы стандартного log
+// тоже уходят в него.
+func Setup(opts Options) *slog.Logger {
+	l := New(opts)
+	slog.SetDefault(l)
+	return l
+}
+
+// ParseLevel разбирает debug|info|warn|error; по умолчанию info.
+func ParseLevel(s string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+func redact(_ []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() != slog.KindGroup && IsSecret(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+	return a
+}
+
+type ctxKey struct{}
+
+// WithLogger кладёт логгер в контекст.
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext возвращает логгер из контекста или логгер по умолчанию.
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// FromFiber — логгер запроса. user_id и session_id добавляются, если запрос
+// уже прошёл JWTAuth.
+func FromFiber(c *fiber.Ctx) *slog.Logger {
+	l := FromContext(c.UserContext())
+	if uid, _ := c.Locals("user_id").(string); uid != "" {
+		l = l.With("user_id", uid)
+	}
+	if sid, _ := c.Locals("session_id").(string); sid != "" {
+		l = l.With("session_id", sid)
+	}
+	return l
+}
+
+// Middleware создаёт логгер запроса (request_id, trace_id) и пишет строку
+// access-лога после обработки. Ставится после requestid и tracing.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		l := slog.Default()
+		if rid, _ := c.Locals("requestid").(string); rid != "" {
+			l = l.With("request_id", rid)
+		}
+		if sc := trace.SpanContextFromContext(c.UserContext()); sc.HasTraceID() {
+			l = l.With("trace_id", sc.TraceID().String())
+		}
+		c.SetUserContext(WithLogger(c.UserContext(), l))
+		method, path := utils.CopyString(c.Method()), utils.CopyString(c.Path())
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		var fe *fiber.Error
+		switch {
+		case errors.As(err, &fe):
+			status = fe.Code
+		case err != nil:
+			// ответ ещё не записан — его сформирует ErrorHandler
+			status = fiber.StatusInternalServerError
+		}
+		level := slog.LevelInfo
+		if status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		FromFiber(c).Log(c.UserContext(), level, "http request",
+			"method", method,
+			"path", path,
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"ip", c.IP(),
+		)
+		return err
+	}
+}
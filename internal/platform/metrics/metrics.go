@@ -0,0 +1,51 @@
+// Package metrics — метрики Prometheus для HTTP-сервера (/metrics).
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "http_request_duration_seconds",
+	Help:    "Длительность обработки HTTP-запросов.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"method", "route", "status"})
+
+// Middleware замеряет запросы. route — шаблон маршрута (/api/v1/user/devices/:device_id),
+// а не фактический путь, чтобы не раздувать число рядов.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		// строки fiber указывают в буфер запроса, который переиспользуется
+		method := utils.CopyString(c.Method())
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if fe, ok := err.(*fiber.Error); ok {
+			status = fe.Code
+		} else if err != nil {
+			status = fiber.StatusInternalServerError
+		}
+		// запрос не дошёл ни до одного маршрута — остался маршрут самого middleware
+		route := c.Route().Path
+		if route == "/" && c.Path() != "/" {
+			route = "unmatched"
+		}
+		httpDuration.WithLabelValues(method, utils.CopyString(route), strconv.Itoa(status)).
+			Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// Handler отдаёт метрики в формате Prometheus.
+func Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.Handler())
+}
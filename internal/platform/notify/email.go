@@ -4,13 +4,35 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"html"
+	"log/slog"
 	"net"
 	"net/smtp"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+
+	"auth/internal/platform/tracing"
 )
 
+// Sender — письма, которые отправляет сервис. Реализация — Mailer (SMTP);
+// в тестах подменяется перехватчиком.
+type Sender interface {
+	SendSignupCode(ctx context.Context, to, code string) error
+	SendResetCode(ctx context.Context, to, code string) error
+	Send2FACode(ctx context.Context, to, code string) error
+	SendPasswordChanged(ctx context.Context, to string) error
+	SendReauthCode(ctx context.Context, to, code string) error
+	SendExportReady(ctx context.Context, to, link string, expiresAt time.Time) error
+	SendNewDeviceAlert(ctx context.Context, to, device, where string, at time.Time, notMeLink string) error
+}
+
+var _ Sender = (*Mailer)(nil)
+
 type Mailer struct {
 	host string
 	port int
@@ -25,9 +47,48 @@ func NewMailer(host string, port int, user, pass, from string) *Mailer {
 	return &Mailer{host: host, port: port, user: user, pass: pass, from: from, InsecureSkipVerify: false}
 }
 
-// send — простая отправка HTML-письма через net/smtp.
-// Работает с MailHog (без аутентификации) и обычными серверами (PlainAuth).
+// Ping проверяет, что SMTP-сервер принимает соединения: приветствие, EHLO и QUIT.
+func (m *Mailer) Ping(ctx context.Context) error {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(m.host, strconv.Itoa(m.port)))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		return err
+	}
+	if err := c.Hello("localhost"); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+var mailSent = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "mail_send_total",
+	Help: "Отправка писем по результату (ok, error).",
+}, []string{"result"})
+
+// send отправляет письмо и учитывает результат в метриках.
 func (m *Mailer) send(ctx context.Context, to, subject, htmlBody string) error {
+	ctx, span := tracing.Start(ctx, "mail.send", attribute.String("smtp.host", m.host))
+	err := m.deliver(ctx, to, subject, htmlBody)
+	tracing.End(span, err)
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	mailSent.WithLabelValues(result).Inc()
+	return err
+}
+
+// deliver — простая отправка HTML-письма через net/smtp.
+// Работает с MailHog (без аутентификации) и обычными серверами (PlainAuth).
+func (m *Mailer) deliver(ctx context.Context, to, subject, htmlBody string) error {
 	// MIME
 	headers := map[string]string{
 		"From":         m.from,
@@ -68,7 +129,7 @@ func (m *Mailer) send(ctx context.Context, to, subject, htmlBody string) error {
 	// Close SMTP client on return; log Quit error if any.
 	defer func() {
 		if err := c.Quit(); err != nil {
-			fmt.Printf("smtp client quit error: %v\n", err)
+			slog.Warn("smtp quit", "err", err)
 		}
 	}()
 
@@ -156,3 +217,32 @@ func (m *Mailer) Send2FACode(ctx context.Context, to, code string) error {
 		`<h2>Вход в аккаунт</h2><p>Ваш 2FA-код: <b>%s</b></p><p>Код действителен 10 минут.</p>`, code)
 	return m.send(ctx, to, "Код подтверждения входа (2FA)", body)
 }
+
+func (m *Mailer) SendPasswordChanged(ctx context.Context, to string) error {
+	body := `<h2>Пароль изменён</h2><p>Пароль от вашего аккаунта был изменён, остальные сеансы завершены.</p>` +
+		`<p>Если это были не вы, срочно восстановите доступ через «Забыли пароль».</p>`
+	return m.send(ctx, to, "Пароль изменён", body)
+}
+
+func (m *Mailer) SendReauthCode(ctx context.Context, to, code string) error {
+	body := fmt.Sprintf(
+		`<h2>Подтверждение действия</h2><p>Код для подтверждения удаления аккаунта: <b>%s</b></p><p>Код действителен 10 минут.</p>`, code)
+	return m.send(ctx, to, "Подтверждение удаления аккаунта", body)
+}
+
+func (m *Mailer) SendExportReady(ctx context.Context, to, link string, expiresAt time.Time) error {
+	body := fmt.Sprintf(
+		`<h2>Выгрузка данных готова</h2><p>Скачать архив с вашими данными: <a href="%s">%s</a></p><p>Ссылка действительна до %s (UTC).</p>`,
+		link, link, expiresAt.UTC().Format("02.01.2006 15:04"))
+	return m.send(ctx, to, "Выгрузка ваших данных", body)
+}
+
+// where — IP и, если известно, местоположение.
+func (m *Mailer) SendNewDeviceAlert(ctx context.Context, to, device, where string, at time.Time, notMeLink string) error {
+	body := fmt.Sprintf(
+		`<h2>Вход с нового устройства</h2><p>Устройство: <b>%s</b><br>Откуда: %s<br>Время: %s (UTC)</p>`+
+			`<p>Если это были не вы, перейдите по ссылке — все сеансы будут завершены, а пароль потребуется восстановить: `+
+			`<a href="%s">это был не я</a></p>`,
+		html.EscapeString(device), html.EscapeString(where), at.UTC().Format("02.01.2006 15:04"), notMeLink)
+	return m.send(ctx, to, "Вход с нового устройства", body)
+}
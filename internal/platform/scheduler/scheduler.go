@@ -0,0 +1,131 @@
+// Package scheduler — периодические фоновые задачи сервиса.
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Метрики задач по имени задачи
+var (
+	runs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scheduler_runs_total", Help: "Запуски фоновых задач.",
+	}, []string{"job"})
+	failures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scheduler_errors_total", Help: "Ошибки фоновых задач.",
+	}, []string{"job"})
+	skipped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scheduler_skipped_locked_total", Help: "Пропуски: задачу выполняет другая реплика.",
+	}, []string{"job"})
+	rowsRemoved = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scheduler_rows_removed_total", Help: "Записи, удалённые фоновыми задачами.",
+	}, []string{"job"})
+)
+
+// Job — периодическая задача. Run возвращает число удалённых/обработанных записей.
+type Job struct {
+	Name     string
+	Interval time.Duration // <= 0 — задача выключена
+	// Singleton — задача должна выполняться только на одной реплике;
+	// перед запуском берётся блокировка через Locker.
+	Singleton bool
+	Run       func(ctx context.Context) (int, error)
+}
+
+// Locker — распределённая блокировка по имени задачи (см. db.AdvisoryLocker).
+type Locker interface {
+	// TryLock не ждёт: ok = false, если блокировку держит другая реплика.
+	TryLock(ctx context.Context, name string) (unlock func(), ok bool, err error)
+}
+
+type Scheduler struct {
+	locker Locker
+	jobs   []Job
+	wg     sync.WaitGroup
+}
+
+// New создаёт планировщик. Без locker singleton-задачи выполняются на каждой реплике.
+func New(locker Locker) *Scheduler { return &Scheduler{locker: locker} }
+
+func (s *Scheduler) Add(jobs ...Job) { s.jobs = append(s.jobs, jobs...) }
+
+// Start запускает задачи; они останавливаются при отмене ctx.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, j := range s.jobs {
+		if j.Interval <= 0 {
+			continue
+		}
+		s.wg.Add(1)
+		go func(j Job) {
+			defer s.wg.Done()
+			s.loop(ctx, j)
+		}(j)
+	}
+}
+
+// Wait ждёт завершения задач после отмены ctx.
+func (s *Scheduler) Wait() { s.wg.Wait() }
+
+func (s *Scheduler) loop(ctx context.Context, j Job) {
+	t := time.NewTicker(j.Interval)
+	defer t.Stop()
+	for {
+		s.runOnce(ctx, j)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, j Job) {
+	if j.Singleton && s.locker != nil {
+		unlock, ok, err := s.locker.TryLock(ctx, j.Name)
+		if err != nil {
+			failures.WithLabelValues(j.Name).Inc()
+			slog.Error("job lock", "job", j.Name, "err", err)
+			return
+		}
+		if !ok {
+			skipped.WithLabelValues(j.Name).Inc()
+			return
+		}
+		defer unlock()
+	}
+
+	runs.WithLabelValues(j.Name).Inc()
+	n, err := j.Run(ctx)
+	rowsRemoved.WithLabelValues(j.Name).Add(float64(n))
+	if err != nil {
+		failures.WithLabelValues(j.Name).Inc()
+		slog.Error("job failed", "job", j.Name, "err", err)
+	}
+	if n > 0 {
+		slog.Info("job done", "job", j.Name, "rows", n)
+	}
+}
+
+// Batches превращает удаление пачками в Run: fn вызывается, пока возвращает
+// полную пачку, чтобы не держать длинную транзакцию на весь объём.
+func Batches(size int, fn func(ctx context.Context, limit int) (int, error)) func(ctx context.Context) (int, error) {
+	return func(ctx context.Context) (int, error) {
+		total := 0
+		for ctx.Err() == nil {
+			n, err := fn(ctx, size)
+			total += n
+			if err != nil {
+				return total, err
+			}
+			if n < size {
+				break
+			}
+		}
+		return total, nil
+	}
+}
@@ -0,0 +1,143 @@
+package security
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// BreachChecker проверяет, встречался ли пароль в известных утечках.
+type BreachChecker interface {
+	Breached(pw string) (bool, error)
+}
+
+const hashPrefixLen = 5
+
+// PrefixFileChecker — офлайн-проверка по модели k-anonymity (как у Have I Been Pwned):
+// по SHA-1 пароля берётся 5-символьный префикс, из локального файла достаётся диапазон
+// хешей с этим префиксом, и суффикс сравнивается уже на нашей стороне.
+//
+// Файл — выгрузка HIBP «ordered by hash»: строки вида `SHA1HEX:COUNT`, отсортированные по хешу.
+// Поиск диапазона — бинарный поиск по смещениям, файл целиком в память не читается.
+type PrefixFileChecker struct {
+	f    *os.File
+	size int64
+}
+
+func NewPrefixFileChecker(path string) (*PrefixFileChecker, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &PrefixFileChecker{f: f, size: st.Size()}, nil
+}
+
+func (c *PrefixFileChecker) Close() error { return c.f.Close() }
+
+func (c *PrefixFileChecker) Breached(pw string) (bool, error) {
+	sum := sha1.Sum([]byte(pw))
+	h := strings.ToUpper(hex.EncodeToString(sum[:]))
+	suffixes, err := c.Range(h[:hashPrefixLen])
+	if err != nil {
+		return false, err
+	}
+	for _, s := range suffixes {
+		if s == h[hashPrefixLen:] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Range возвращает суффиксы всех хешей с заданным префиксом (без счётчиков).
+func (c *PrefixFileChecker) Range(prefix string) ([]string, error) {
+	prefix = strings.ToUpper(prefix)
+	if len(prefix) != hashPrefixLen {
+		return nil, fmt.Errorf("hash prefix must be %d chars", hashPrefixLen)
+	}
+
+	// ищем наименьшее смещение, с которого начинается строка с хешем >= prefix
+	lo, hi := int64(0), c.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		line, err := c.lineFrom(mid)
+		if err != nil {
+			return nil, err
+		}
+		if line == "" || hashPrefix(line) >= prefix {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+
+	start, err := c.lineStart(lo)
+	if err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(io.NewSectionReader(c.f, start, c.size-start))
+	var out []string
+	for {
+		line, err := r.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line != "" {
+			if hashPrefix(line) != prefix {
+				break
+			}
+			hash, _, _ := strings.Cut(line, ":")
+			out = append(out, strings.ToUpper(hash[hashPrefixLen:]))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// lineStart возвращает начало первой строки, начинающейся не раньше off.
+func (c *PrefixFileChecker) lineStart(off int64) (int64, error) {
+	if off <= 0 {
+		return 0, nil
+	}
+	r := bufio.NewReader(io.NewSectionReader(c.f, off-1, c.size-off+1))
+	skipped, err := r.ReadString('\n')
+	if err == io.EOF {
+		return c.size, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return off - 1 + int64(len(skipped)), nil
+}
+
+// lineFrom читает первую строку, начинающуюся не раньше off ("" — конец файла).
+func (c *PrefixFileChecker) lineFrom(off int64) (string, error) {
+	start, err := c.lineStart(off)
+	if err != nil || start >= c.size {
+		return "", err
+	}
+	line, err := bufio.NewReader(io.NewSectionReader(c.f, start, c.size-start)).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func hashPrefix(line string) string {
+	if len(line) < hashPrefixLen {
+		return strings.ToUpper(line)
+	}
+	return strings.ToUpper(line[:hashPrefixLen])
+}
@@ -4,37 +4,38 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+
+	"auth/internal/platform/clock"
 )
 
 type JWTManager struct {
 	secret    []byte
 	accessTTL time.Duration
+	clock     clock.Clock
 }
 
 func NewJWTManager(secret string, accessTTL time.Duration) *JWTManager {
-	return &JWTManager{secret: []byte(secret), accessTTL: accessTTL}
+	return &JWTManager{secret: []byte(secret), accessTTL: accessTTL, clock: clock.System{}}
 }
 
-func (j *JWTManager) IssueAccess(userID, role, sessionID string) (string, time.Time, error) {
-	exp := time.Now().Add(j.accessTTL)
+// WithClock задаёт часы, от которых считаются iat и exp.
+func (j *JWTManager) WithClock(c clock.Clock) *JWTManager { j.clock = c; return j }
+
+// locale — сохранённый язык пользователя; пустой в токен не попадает.
+func (j *JWTManager) IssueAccess(userID, role, sessionID, locale string) (string, time.Time, error) {
+	now := j.clock.Now()
+	exp := now.Add(j.accessTTL)
 	claims := jwt.MapClaims{
 		"sub":  userID,
-        "role": role,
-        "sid":  sessionID,        // ← добавили sid
+		"role": role,
+		"sid":  sessionID, // ← добавили sid
 		"exp":  exp.Unix(),
-		"iat":  time.Now().Unix(),
+		"iat":  now.Unix(),
+	}
+	if locale != "" {
+		claims["locale"] = locale
 	}
 	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	token, err := t.SignedString(j.secret)
 	return token, exp, err
 }
-
-
-// refresh пока делаем простым random-стрингом
-func IssueRefresh() (string, string, error) {
-	token, err := RandomDigits(30) // простой вариант, потом заменим на crypto/rand
-	if err != nil {
-		return "", "", err
-	}
-	return token, token, nil
-}
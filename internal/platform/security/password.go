@@ -1,11 +1,111 @@
 package security
 
-import "github.com/alexedwards/argon2id"
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
 
+	"github.com/alexedwards/argon2id"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+var ErrUnknownHashFormat = errors.New("unknown password hash format")
+
+// текущие параметры argon2id для новых хешей (задаются из конфига через SetHashParams)
+var hashParams = argon2id.DefaultParams
+
+// SetHashParams задаёт параметры argon2id. Хеши со слабыми параметрами
+// будут перехешированы при следующем успешном входе.
+func SetHashParams(p *argon2id.Params) {
+	if p != nil {
+		hashParams = p
+	}
+}
 
 func HashPassword(pw string) (string, error) {
-	return argon2id.CreateHash(pw, argon2id.DefaultParams)
+	return argon2id.CreateHash(pw, hashParams)
+}
+
+// CheckPassword сверяет пароль с хешем. needsRehash = true, если хеш в устаревшем
+// формате (bcrypt/scrypt после импорта пользователей) или argon2id с параметрами слабее текущих.
+func CheckPassword(hash, pw string) (ok, needsRehash bool, err error) {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		ok, params, err := argon2id.CheckHash(pw, hash)
+		if err != nil || !ok {
+			return false, false, err
+		}
+		return true, weakerParams(params, hashParams), nil
+
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(pw))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, err
+		}
+		return true, true, nil
+
+	case strings.HasPrefix(hash, "$scrypt$"):
+		ok, err := checkScrypt(hash, pw)
+		return ok, ok, err
+	}
+	return false, false, ErrUnknownHashFormat
+}
+
+func weakerParams(have, want *argon2id.Params) bool {
+	return have.Memory < want.Memory ||
+		have.Iterations < want.Iterations ||
+		have.Parallelism < want.Parallelism ||
+		have.SaltLength < want.SaltLength ||
+		have.KeyLength < want.KeyLength
+}
+
+// checkScrypt проверяет хеш в формате passlib: $scrypt$ln=<log2 N>,r=<r>,p=<p>$<salt>$<key>
+// (salt и key — base64 без паддинга, passlib использует '.' вместо '+').
+func checkScrypt(hash, pw string) (bool, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 5 {
+		return false, ErrUnknownHashFormat
+	}
+	var ln, r, p int
+	for _, kv := range strings.Split(parts[2], ",") {
+		k, v, _ := strings.Cut(kv, "=")
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return false, ErrUnknownHashFormat
+		}
+		switch k {
+		case "ln":
+			ln = n
+		case "r":
+			r = n
+		case "p":
+			p = n
+		}
+	}
+	if ln <= 0 || ln > 30 || r <= 0 || p <= 0 {
+		return false, ErrUnknownHashFormat
+	}
+	salt, err := decodeAB64(parts[3])
+	if err != nil {
+		return false, err
+	}
+	want, err := decodeAB64(parts[4])
+	if err != nil {
+		return false, err
+	}
+	got, err := scrypt.Key([]byte(pw), salt, 1<<ln, r, p, len(want))
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
+
+func decodeAB64(s string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(strings.ReplaceAll(strings.TrimRight(s, "="), ".", "+"))
 }
-func CheckPassword(hash, pw string) (bool, error) {
-	return argon2id.ComparePasswordAndHash(pw, hash)
-}
\ No newline at end of file
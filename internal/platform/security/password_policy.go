@@ -0,0 +1,175 @@
+package security
+
+import (
+	"bufio"
+	_ "embed"
+	"log/slog"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Топ самых распространённых паролей, отсортирован по частоте (самые частые — сверху).
+//
+//go:embed common_passwords.txt
+var commonPasswordsRaw string
+
+// PasswordViolation — нарушенное правило политики паролей.
+type PasswordViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Идентификаторы правил (уходят клиенту в details[].rule).
+const (
+	RuleMinLength = "min_length"
+	RuleMaxLength = "max_length"
+	RuleLetter    = "letter"
+	RuleUpper     = "upper"
+	RuleDigit     = "digit"
+	RuleSymbol    = "symbol"
+	RulePersonal  = "personal_data"
+	RuleCommon    = "common"
+	RuleBreached  = "breached"
+)
+
+// PasswordPolicy — единые правила для паролей: регистрация, сброс и смена пароля.
+type PasswordPolicy struct {
+	MinLength int
+	MaxLength int
+
+	RequireLetter bool
+	RequireUpper  bool
+	RequireDigit  bool
+	RequireSymbol bool
+
+	// DisallowPersonal запрещает пароли, содержащие email или имя пользователя.
+	DisallowPersonal bool
+	// CommonTopN — сколько паролей из встроенного списка считать «слишком частыми» (0 — проверка выключена).
+	// Значение больше длины списка (см. CommonPasswordsLen) равносильно всему списку.
+	CommonTopN int
+	// Breached — проверка по базе утёкших паролей (nil — проверка выключена).
+	Breached BreachChecker
+}
+
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:        8,
+		MaxLength:        50,
+		RequireLetter:    true,
+		RequireDigit:     true,
+		DisallowPersonal: true,
+		CommonTopN:       CommonPasswordsLen(),
+	}
+}
+
+// Validate проверяет пароль и возвращает все нарушенные правила (nil — пароль подходит).
+// personal — email, имя, фамилия и т.п., которые не должны входить в пароль.
+func (p PasswordPolicy) Validate(pw string, personal ...string) []PasswordViolation {
+	var out []PasswordViolation
+	add := func(rule, msg string) { out = append(out, PasswordViolation{Rule: rule, Message: msg}) }
+
+	n := utf8.RuneCountInString(pw)
+	if p.MinLength > 0 && n < p.MinLength {
+		add(RuleMinLength, "Пароль слишком короткий")
+	}
+	if p.MaxLength > 0 && n > p.MaxLength {
+		add(RuleMaxLength, "Пароль слишком длинный")
+	}
+
+	var hasLetter, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range pw {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+			if unicode.IsUpper(r) {
+				hasUpper = true
+			}
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireLetter && !hasLetter {
+		add(RuleLetter, "Пароль должен содержать хотя бы одну букву")
+	}
+	if p.RequireUpper && !hasUpper {
+		add(RuleUpper, "Пароль должен содержать хотя бы одну заглавную букву")
+	}
+	if p.RequireDigit && !hasDigit {
+		add(RuleDigit, "Пароль должен содержать хотя бы одну цифру")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		add(RuleSymbol, "Пароль должен содержать хотя бы один спецсимвол")
+	}
+
+	lower := strings.ToLower(pw)
+	if p.DisallowPersonal && containsPersonal(lower, personal) {
+		add(RulePersonal, "Пароль не должен содержать email или имя")
+	}
+	if p.isCommon(lower) {
+		add(RuleCommon, "Пароль слишком распространённый")
+	}
+
+	// проверку по утечкам делаем последней и только для паролей, прошедших остальные правила
+	if len(out) == 0 && p.Breached != nil {
+		breached, err := p.Breached.Breached(pw)
+		if err != nil {
+			// недоступность базы утечек не должна блокировать регистрацию
+			slog.Error("password breach check", "err", err)
+		} else if breached {
+			add(RuleBreached, "Пароль встречается в утечках данных, выберите другой")
+		}
+	}
+	return out
+}
+
+func containsPersonal(lowerPw string, personal []string) bool {
+	for _, v := range personal {
+		v = strings.ToLower(strings.TrimSpace(v))
+		// для email сравниваем только локальную часть
+		if i := strings.IndexByte(v, '@'); i >= 0 {
+			v = v[:i]
+		}
+		if utf8.RuneCountInString(v) >= 3 && strings.Contains(lowerPw, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p PasswordPolicy) isCommon(lowerPw string) bool {
+	if p.CommonTopN <= 0 {
+		return false
+	}
+	commonOnce.Do(loadCommonPasswords)
+	rank, ok := commonRank[lowerPw]
+	return ok && rank < p.CommonTopN
+}
+
+// CommonPasswordsLen — сколько паролей во встроенном списке; по умолчанию проверяется весь список.
+func CommonPasswordsLen() int {
+	commonOnce.Do(loadCommonPasswords)
+	return len(commonRank)
+}
+
+var (
+	commonOnce sync.Once
+	commonRank map[string]int // пароль -> позиция в списке
+)
+
+func loadCommonPasswords() {
+	commonRank = make(map[string]int)
+	sc := bufio.NewScanner(strings.NewReader(commonPasswordsRaw))
+	for sc.Scan() {
+		line := strings.ToLower(strings.TrimSpace(sc.Text()))
+		if line == "" {
+			continue
+		}
+		if _, dup := commonRank[line]; !dup {
+			commonRank[line] = len(commonRank)
+		}
+	}
+}
@@ -3,9 +3,21 @@ package security
 import (
 	"crypto/rand"
 	"fmt"
+	"io"
 )
 
-func RandomDigits(n int) (string, error) {
+// Random — источник кодов подтверждения и refresh-токенов. В тестах подменяется
+// детерминированным, чтобы ответы API можно было сравнивать с эталоном.
+type Random struct {
+	r io.Reader // должен быть безопасен для параллельного чтения
+}
+
+func NewRandom(r io.Reader) *Random { return &Random{r: r} }
+
+// CryptoRandom — crypto/rand, источник по умолчанию.
+var CryptoRandom = NewRandom(rand.Reader)
+
+func (g *Random) Digits(n int) (string, error) {
 	if n <= 0 {
 		n = 6
 	}
@@ -16,7 +28,7 @@ func RandomDigits(n int) (string, error) {
 	}
 	// берём достаточно байт и модулируем
 	buf := make([]byte, 8)
-	if _, err := rand.Read(buf); err != nil {
+	if _, err := io.ReadFull(g.r, buf); err != nil {
 		return "", err
 	}
 	// простая и достаточная для кода мод-редукция
@@ -26,3 +38,8 @@ func RandomDigits(n int) (string, error) {
 	}
 	return fmt.Sprintf("%0*d", n, val), nil
 }
+
+// RefreshToken — новый refresh-токен.
+func (g *Random) RefreshToken() (string, error) {
+	return g.Digits(30) // простой вариант, потом заменим на crypto/rand
+}
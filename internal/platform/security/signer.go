@@ -0,0 +1,49 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth/internal/platform/clock"
+)
+
+// Signer подписывает короткоживущие ссылки (HMAC-SHA256), чтобы их можно было
+// открыть без авторизации, например из письма.
+type Signer struct {
+	secret []byte
+	clock  clock.Clock
+}
+
+func NewSigner(secret string) *Signer {
+	return &Signer{secret: []byte(secret), clock: clock.System{}}
+}
+
+// WithClock задаёт часы, по которым Verify проверяет срок действия ссылки.
+func (s *Signer) WithClock(c clock.Clock) *Signer { s.clock = c; return s }
+
+// Sign возвращает подпись для набора значений и времени истечения.
+func (s *Signer) Sign(exp time.Time, parts ...string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(strconv.FormatInt(exp.Unix(), 10)))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись и срок действия; exp — unix-время из ссылки.
+func (s *Signer) Verify(sig, exp string, parts ...string) bool {
+	ts, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return false
+	}
+	expAt := time.Unix(ts, 0)
+	if s.clock.Now().After(expAt) {
+		return false
+	}
+	want := s.Sign(expAt, parts...)
+	return hmac.Equal([]byte(sig), []byte(want))
+}
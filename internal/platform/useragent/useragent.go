@@ -0,0 +1,143 @@
+// Package useragent — упрощённый разбор User-Agent: браузер, ОС и тип устройства.
+package useragent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const Other = "Other"
+
+// Типы устройств
+const (
+	Desktop = "desktop"
+	Mobile  = "mobile"
+	Tablet  = "tablet"
+	Bot     = "bot"
+)
+
+type Info struct {
+	Browser        string
+	BrowserVersion string
+	OS             string
+	OSVersion      string
+	DeviceType     string
+}
+
+// порядок важен: Edge/Opera/Яндекс содержат "Chrome", Chrome содержит "Safari"
+var browsers = []struct{ token, name string }{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"YaBrowser/", "Yandex Browser"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"Firefox/", "Firefox"},
+	{"FxiOS/", "Firefox"},
+	{"Chrome/", "Chrome"},
+	{"CriOS/", "Chrome"},
+	{"Version/", "Safari"}, // у Safari версия браузера в Version/, а Safari/ — номер WebKit
+	{"Safari/", "Safari"},
+	{"okhttp/", "OkHttp"},
+	{"curl/", "curl"},
+	{"PostmanRuntime/", "Postman"},
+}
+
+// Android и ChromeOS проверяем раньше Linux; токен — то, после чего идёт версия
+var systems = []struct{ token, name string }{
+	{"Windows NT ", "Windows"},
+	{"Windows", "Windows"},
+	{"Android ", "Android"},
+	{"Android", "Android"},
+	{"iPhone OS ", "iOS"},
+	{"iPad; CPU OS ", "iPadOS"},
+	{"iPad", "iPadOS"},
+	{"iPhone", "iOS"},
+	{"iPod", "iOS"},
+	{"CrOS", "ChromeOS"},
+	{"Mac OS X ", "macOS"},
+	{"Macintosh", "macOS"},
+	{"Linux", "Linux"},
+}
+
+var windowsVersions = map[string]string{
+	"10.0": "10/11", "6.3": "8.1", "6.2": "8", "6.1": "7",
+}
+
+func Parse(ua string) Info {
+	var info Info
+	info.Browser, info.BrowserVersion = match(ua, browsers)
+	info.OS, info.OSVersion = match(ua, systems)
+	info.OSVersion = strings.ReplaceAll(info.OSVersion, "_", ".")
+	if info.OS == "Windows" {
+		if v, ok := windowsVersions[info.OSVersion]; ok {
+			info.OSVersion = v
+		}
+	}
+	info.DeviceType = deviceType(ua, info)
+	return info
+}
+
+// match возвращает название и версию (символы сразу после токена до разделителя).
+func match(ua string, table []struct{ token, name string }) (string, string) {
+	for _, t := range table {
+		i := strings.Index(ua, t.token)
+		if i < 0 {
+			continue
+		}
+		rest := ua[i+len(t.token):]
+		end := strings.IndexAny(rest, " ;)")
+		if end < 0 {
+			end = len(rest)
+		}
+		return t.name, versionOnly(rest[:end])
+	}
+	return Other, ""
+}
+
+func versionOnly(s string) string {
+	if s == "" || s[0] < '0' || s[0] > '9' {
+		return ""
+	}
+	return s
+}
+
+// признаки ботов в нижнем регистре. "bot" ищем только перед разделителем
+// (Googlebot/2.1, bingbot;), иначе ботами окажутся телефоны Cubot и т. п.;
+// краулеры без "bot" в названии перечислены явно
+var botTokens = []string{
+	"bot/", "bot;", "bot)", "crawler", "spider", "slackbot",
+	"facebookexternalhit", "slurp", "ia_archiver", "embedly", "whatsapp/",
+}
+
+func isBot(ua string) bool {
+	lower := strings.ToLower(ua)
+	for _, t := range botTokens {
+		if strings.Contains(lower, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func deviceType(ua string, info Info) string {
+	switch {
+	case isBot(ua):
+		return Bot
+	case info.OS == "iPadOS" || (info.OS == "Android" && !strings.Contains(ua, "Mobile")):
+		return Tablet
+	case info.OS == "iOS" || info.OS == "Android" || strings.Contains(ua, "Mobile"):
+		return Mobile
+	case info.OS == "Windows" || info.OS == "macOS" || info.OS == "Linux" || info.OS == "ChromeOS":
+		return Desktop
+	}
+	return Other
+}
+
+// Fingerprint — отпечаток устройства: семейство браузера и ОС плюс стабильный
+// device_id, который присылает клиент. Без device_id отпечаток грубый, но версия
+// браузера в него не входит, чтобы обновления не выглядели как новое устройство.
+func Fingerprint(ua, deviceID string) string {
+	info := Parse(ua)
+	sum := sha256.Sum256([]byte(info.Browser + "|" + info.OS + "|" + strings.TrimSpace(deviceID)))
+	return hex.EncodeToString(sum[:16])
+}
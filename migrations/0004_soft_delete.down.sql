@@ -0,0 +1,4 @@
+-- значение 'reauth' из code_kind удалить нельзя, оно остаётся
+DROP INDEX IF EXISTS idx_users_purge_after;
+ALTER TABLE users DROP COLUMN IF EXISTS purge_after;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,6 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS purge_after timestamptz;
+CREATE INDEX IF NOT EXISTS idx_users_purge_after ON users(purge_after) WHERE purge_after IS NOT NULL;
+
+-- код повторной аутентификации для аккаунтов без пароля (OAuth)
+ALTER TYPE code_kind ADD VALUE IF NOT EXISTS 'reauth';
@@ -0,0 +1,5 @@
+ALTER TABLE users DROP COLUMN IF EXISTS password_reset_required;
+
+DROP INDEX IF EXISTS idx_sessions_user_fingerprint;
+ALTER TABLE sessions DROP COLUMN IF EXISTS fingerprint;
+ALTER TABLE sessions DROP COLUMN IF EXISTS device_id;
@@ -0,0 +1,5 @@
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS device_id text;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS fingerprint text;
+CREATE INDEX IF NOT EXISTS idx_sessions_user_fingerprint ON sessions(user_id, fingerprint);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_reset_required boolean NOT NULL DEFAULT false;
@@ -0,0 +1,2 @@
+ALTER TABLE sessions DROP COLUMN IF EXISTS country;
+ALTER TABLE sessions DROP COLUMN IF EXISTS city;
@@ -0,0 +1,2 @@
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS city text;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS country text;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_sessions_revoked;
+DROP INDEX IF EXISTS idx_sessions_expires;
+DROP INDEX IF EXISTS idx_codes_expires;
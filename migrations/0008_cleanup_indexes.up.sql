@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_codes_expires ON verification_codes(expires_at);
+CREATE INDEX IF NOT EXISTS idx_sessions_expires ON sessions(expires_at);
+CREATE INDEX IF NOT EXISTS idx_sessions_revoked ON sessions(revoked_at) WHERE revoked_at IS NOT NULL;
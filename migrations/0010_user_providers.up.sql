@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS user_providers (
+  user_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  provider   TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (user_id, provider)
+);
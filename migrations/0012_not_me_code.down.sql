@@ -0,0 +1,2 @@
+-- значение 'not_me' из code_kind удалить нельзя, оно остаётся
+DELETE FROM verification_codes WHERE kind = 'not_me';
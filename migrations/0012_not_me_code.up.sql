@@ -0,0 +1 @@
+ALTER TYPE code_kind ADD VALUE IF NOT EXISTS 'not_me';
@@ -0,0 +1,16 @@
+-- история устройств для писем о входе с нового устройства; в отличие от
+-- sessions не чистится фоновой задачей
+CREATE TABLE IF NOT EXISTS user_devices (
+  user_id     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  fingerprint TEXT NOT NULL,
+  first_seen  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  last_seen   TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (user_id, fingerprint)
+);
+
+INSERT INTO user_devices (user_id, fingerprint, first_seen, last_seen)
+SELECT user_id, fingerprint, MIN(created_at), MAX(last_active)
+  FROM sessions
+ WHERE fingerprint IS NOT NULL
+ GROUP BY user_id, fingerprint
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,8 @@
+// Package migrations — SQL-миграции схемы, встроенные в бинарник (см. db.Migrator).
+// Имена файлов — в формате golang-migrate: <версия>_<название>.(up|down).sql.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS